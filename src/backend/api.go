@@ -0,0 +1,160 @@
+// backend/api.go
+package main
+
+import (
+	_ "embed"
+	"net/http"
+	"strings"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// -----------------------------------------------------------------------------
+// API versions
+// -----------------------------------------------------------------------------
+
+// apiV1 is the versioned API root. The unversioned /api routes are kept as
+// compatibility aliases: same handlers, but /api/find keeps its old body.
+const (
+	apiV1     = "/api/v1"
+	apiLegacy = "/api"
+)
+
+// route registers h under /api/v1 and under the /api alias, each counted
+// separately in the request metrics
+func route(path string, h http.HandlerFunc) {
+	for _, prefix := range []string{apiV1, apiLegacy} {
+		http.HandleFunc(prefix+path, instrument(prefix+path, h))
+	}
+}
+
+// isV1 reports whether a request came in through /api/v1
+func isV1(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, apiV1+"/")
+}
+
+// apiPath returns the request path below the API root ("/element/Water")
+func apiPath(r *http.Request) string {
+	if isV1(r) {
+		return strings.TrimPrefix(r.URL.Path, apiV1)
+	}
+	return strings.TrimPrefix(r.URL.Path, apiLegacy)
+}
+
+// openAPIDoc is the hand-written OpenAPI 3 description of /api/v1; keep it
+// in step with the types below
+//
+//go:embed openapi.json
+var openAPIDoc []byte
+
+// handleOpenAPI serves /api/v1/openapi.json
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDoc)
+}
+
+// -----------------------------------------------------------------------------
+// Search results
+// -----------------------------------------------------------------------------
+
+// FindResult is the body of a successful /api/v1/find response
+type FindResult struct {
+	Target       string                     `json:"target"`
+	Algorithm    string                     `json:"algorithm"`
+	Multi        bool                       `json:"multi"`
+	Trees        []*recipeFinder.RecipeNode `json:"trees"` // Exactly one tree when multi=false
+	NodesVisited int                        `json:"nodes_visited"`
+	DurationMs   float64                    `json:"duration_ms"`
+	SearchSteps  *SearchSteps               `json:"search_steps,omitempty"` // Left out for steps=none
+
+	// Truncated is set when the time budget ran out; Trees then holds partial results
+	Truncated bool      `json:"truncated,omitempty"`
+	Warning   *APIError `json:"warning,omitempty"`
+
+	// Precomputed is set when the answer came from the warm-up recipe table
+	Precomputed bool `json:"precomputed,omitempty"`
+}
+
+// Search step formats, depending on ?steps= (the same for every algorithm)
+const (
+	stepsSnapshots = "snapshots" // steps=full: the whole search state per step
+	stepsDelta     = "delta"     // steps=delta: only the changes, with keyframes
+)
+
+// SearchSteps is the recorded search for the visualizer; only the field
+// named by Format is set
+type SearchSteps struct {
+	Format    string                    `json:"format"`
+	Snapshots []recipeFinder.SearchStep `json:"snapshots,omitempty"`
+	Delta     *recipeFinder.DeltaSteps  `json:"delta,omitempty"`
+}
+
+// legacy converts a result to the unversioned /api/find body, where tree is
+// a single tree or an array depending on multi and search_steps is untagged
+func (res FindResult) legacy() FindResponse {
+	resp := FindResponse{
+		DurationMs:   res.DurationMs,
+		Algorithm:    res.Algorithm,
+		NodesVisited: res.NodesVisited,
+		Truncated:    res.Truncated,
+		Warning:      res.Warning,
+		Precomputed:  res.Precomputed,
+	}
+	if res.Multi {
+		resp.Tree = res.Trees
+	} else if len(res.Trees) > 0 {
+		resp.Tree = res.Trees[0]
+	}
+	if s := res.SearchSteps; s != nil {
+		switch s.Format {
+		case stepsSnapshots:
+			resp.SearchSteps = s.Snapshots
+		case stepsDelta:
+			resp.SearchSteps = s.Delta
+		}
+	}
+	return resp
+}
+
+// Payloads of the /find/stream Server-Sent Events
+type (
+	// StreamTreeEvent ("tree") carries one recipe tree as soon as it is found
+	StreamTreeEvent struct {
+		Index int                      `json:"index"`
+		Tree  *recipeFinder.RecipeNode `json:"tree"`
+	}
+
+	// StreamProgressEvent ("progress") is sent periodically while searching
+	StreamProgressEvent struct {
+		Trees     int   `json:"trees"`
+		ElapsedMs int64 `json:"elapsed_ms"`
+	}
+
+	// StreamDoneEvent ("done") ends a successful stream
+	StreamDoneEvent struct {
+		Algorithm    string    `json:"algorithm"`
+		Trees        int       `json:"trees"`
+		NodesVisited int       `json:"nodes_visited"`
+		DurationMs   float64   `json:"duration_ms"`
+		Truncated    bool      `json:"truncated,omitempty"`
+		Warning      *APIError `json:"warning,omitempty"`
+	}
+)
+
+// -----------------------------------------------------------------------------
+// Other responses
+// -----------------------------------------------------------------------------
+
+// ElementsResponse is the body of /api/v1/elements
+type ElementsResponse struct {
+	Query    string         `json:"query"`
+	Elements []ElementMatch `json:"elements"`
+}
+
+// ScrapeResponse is the body of a successful /api/v1/scrape
+type ScrapeResponse struct {
+	Status        string                       `json:"status"` // always "success"
+	Message       string                       `json:"message"`
+	ElementsCount int                          `json:"elements_count"` // Number of tiers, kept for old clients
+	Warnings      []recipeFinder.ScrapeWarning `json:"warnings"`
+}
@@ -0,0 +1,106 @@
+// backend/apierror.go
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// -----------------------------------------------------------------------------
+// Error codes shared by every endpoint
+// -----------------------------------------------------------------------------
+const (
+	codeUnknownElement     = "unknown_element"     // name didn't resolve to any element
+	codeUnreachableElement = "unreachable_element" // element exists but no recipe reaches it from the base set
+	codeInvalidParameter   = "invalid_parameter"   // missing or malformed query parameter
+	codeTimeout            = "timeout"             // search ran out of its time budget
+	codeResultTruncated    = "result_truncated"    // search stopped early, result is partial
+	codeMethodNotAllowed   = "method_not_allowed"  // wrong HTTP method
+	codeScrapeFailed       = "scrape_failed"       // scraping or saving the catalog failed
+	codeNotReady           = "not_ready"           // no catalog loaded yet
+	codeUnauthorized       = "unauthorized"        // admin endpoint without a valid token
+	codeAdminDisabled      = "admin_disabled"      // admin endpoint, but no -admin-token configured
+	codeRateLimited        = "rate_limited"        // client exceeded its request rate
+	codeServerBusy         = "server_busy"         // too many searches running at once
+	codeInternal           = "internal_error"      // anything else
+)
+
+// APIError is the body of every error response: {"error": {...}}
+type APIError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// Error lets an APIError travel as an error value (GraphQL resolvers return them)
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// errorEnvelope wraps an APIError so clients can always check for an "error" key
+type errorEnvelope struct {
+	Error APIError `json:"error"`
+}
+
+// writeError sends an error envelope with the given HTTP status
+func writeError(w http.ResponseWriter, status int, code, message string, details interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorEnvelope{Error: APIError{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}})
+}
+
+// writeInvalidParameter answers 400 for a missing or malformed query parameter
+func writeInvalidParameter(w http.ResponseWriter, param, message string) {
+	writeError(w, http.StatusBadRequest, codeInvalidParameter, message,
+		map[string]string{"parameter": param})
+}
+
+// writeMethodNotAllowed answers 405 and advertises the allowed method
+func writeMethodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeError(w, http.StatusMethodNotAllowed, codeMethodNotAllowed,
+		"method not allowed, use "+allowed, nil)
+}
+
+// writeAPIError sends e with the given HTTP status
+func writeAPIError(w http.ResponseWriter, status int, e APIError) {
+	writeError(w, status, e.Code, e.Message, e.Details)
+}
+
+// unknownElementError is the error for a name that didn't resolve, with "did you mean" suggestions
+func unknownElementError(name string) APIError {
+	return APIError{
+		Code:    codeUnknownElement,
+		Message: "unknown element " + strconv.Quote(name),
+		Details: map[string]interface{}{
+			"target":      name,
+			"suggestions": recipeFinder.GlobalNameResolver.Suggest(name, 5),
+		},
+	}
+}
+
+// unreachableElementError is the error for an element no recipe chain reaches
+func unreachableElementError(name, algorithm string) APIError {
+	return APIError{
+		Code:    codeUnreachableElement,
+		Message: "no recipe reaches " + strconv.Quote(name) + " from the base elements",
+		Details: map[string]string{"target": name, "algorithm": algorithm},
+	}
+}
+
+// writeUnknownElement answers 404 with "did you mean" suggestions for a name that didn't resolve
+func writeUnknownElement(w http.ResponseWriter, name string) {
+	writeAPIError(w, http.StatusNotFound, unknownElementError(name))
+}
+
+// writeUnreachableElement answers 404 for an element no recipe chain reaches
+func writeUnreachableElement(w http.ResponseWriter, name, algorithm string) {
+	writeAPIError(w, http.StatusNotFound, unreachableElementError(name, algorithm))
+}
@@ -0,0 +1,40 @@
+// backend/auth.go
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// requireAdmin only lets requests carrying the admin token through:
+//
+//	Authorization: Bearer <token>
+//
+// Without -admin-token every admin request is refused, so a server that
+// was started without one can't be told to scrape by anyone.
+func requireAdmin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if *adminToken == "" {
+			writeError(w, http.StatusForbidden, codeAdminDisabled,
+				"admin endpoints are disabled, start the server with -admin-token", nil)
+			return
+		}
+		if !validAdminToken(r.Header.Get("Authorization")) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeError(w, http.StatusUnauthorized, codeUnauthorized, "missing or invalid admin token", nil)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// validAdminToken checks an Authorization header against -admin-token in constant time
+func validAdminToken(header string) bool {
+	const prefix = "Bearer "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return false
+	}
+	token := strings.TrimSpace(header[len(prefix):])
+	return subtle.ConstantTimeCompare([]byte(token), []byte(*adminToken)) == 1
+}
@@ -0,0 +1,266 @@
+// backend/batch.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// maxBatchBody caps the size of a batch request body
+const maxBatchBody = 1 << 20
+
+// BatchFindRequest is the body of POST /api/v1/find/batch. The options are
+// those of /find and apply to every target; steps defaults to none here.
+type BatchFindRequest struct {
+	Targets       []string `json:"targets"`
+	MaxPaths      int      `json:"max_paths,omitempty"`  // Default 5
+	Multi         *bool    `json:"multi,omitempty"`      // Default true
+	Algorithm     string   `json:"algorithm,omitempty"`  // bfs (default) | dfs | bidirectional
+	TimeoutMs     int      `json:"timeout_ms,omitempty"` // Budget of the whole batch, default -search-timeout
+	Steps         string   `json:"steps,omitempty"`      // none (default) | full | delta
+	Deterministic bool     `json:"deterministic,omitempty"`
+}
+
+// BatchFindItem is the outcome for one target: Result on success, Error otherwise
+type BatchFindItem struct {
+	Target string      `json:"target"` // As requested
+	Status int         `json:"status"` // HTTP status /find would have answered with
+	Result *FindResult `json:"result,omitempty"`
+	Error  *APIError   `json:"error,omitempty"`
+	Cached bool        `json:"cached,omitempty"` // Answered from the /find response cache
+}
+
+// BatchFindResponse is the body of POST /api/v1/find/batch; Results are in request order
+type BatchFindResponse struct {
+	Snapshot   string          `json:"snapshot"` // Catalog version every target was searched on
+	Results    []BatchFindItem `json:"results"`
+	Succeeded  int             `json:"succeeded"`
+	Failed     int             `json:"failed"`
+	DurationMs float64         `json:"duration_ms"`
+}
+
+// params validates the shared options. On failure it returns the offending
+// field and a message for the invalid_parameter error.
+func (req BatchFindRequest) params() (p findParams, field, msg string) {
+	p = findParams{MaxPaths: 5, Multi: true, Algorithm: "bfs", Timeout: *searchTimeout, Steps: "none",
+		Deterministic: req.Deterministic}
+
+	// ---------- targets ----------
+	switch n := len(req.Targets); {
+	case n == 0:
+		return p, "targets", "targets must list at least one element"
+	case n > *batchMaxTargets:
+		return p, "targets", fmt.Sprintf("at most %d targets per batch", *batchMaxTargets)
+	}
+
+	// ---------- max_paths / multi ----------
+	if req.MaxPaths < 0 {
+		return p, "max_paths", "max_paths must be a positive integer"
+	}
+	if req.MaxPaths > 0 {
+		p.MaxPaths = req.MaxPaths
+	}
+	if req.Multi != nil {
+		p.Multi = *req.Multi
+	}
+
+	// ---------- algorithm ----------
+	switch req.Algorithm {
+	case "":
+	case "bfs", "dfs":
+		p.Algorithm = req.Algorithm
+	case "bidirectional":
+		// Single recipe only: implied unless the request sets multi: true
+		if req.Multi != nil && *req.Multi {
+			return p, "multi", "bidirectional search only supports multi=false"
+		}
+		p.Algorithm, p.Multi = req.Algorithm, false
+	default:
+		return p, "algorithm", "algorithm must be bfs, dfs or bidirectional"
+	}
+
+	// ---------- timeout_ms (capped like ?timeoutMs=) ----------
+	if req.TimeoutMs < 0 {
+		return p, "timeout_ms", "timeout_ms must be a positive integer"
+	}
+	if req.TimeoutMs > 0 {
+		p.Timeout = time.Duration(req.TimeoutMs) * time.Millisecond
+	}
+	if p.Timeout > *maxSearchTimeout {
+		p.Timeout = *maxSearchTimeout
+	}
+
+	// ---------- steps ----------
+	switch req.Steps {
+	case "":
+	case "full", "delta", "none":
+		p.Steps = req.Steps
+	default:
+		return p, "steps", "steps must be full, delta or none"
+	}
+	return p, "", ""
+}
+
+// handleFindBatch serves POST /api/v1/find/batch — the same search for many
+// targets in one request:
+//
+//	{"targets": ["Brick", "Mud"], "max_paths": 3, "algorithm": "dfs"}
+//
+// The targets are searched on one snapshot, -batch-workers at a time, under
+// one time budget. Each gets its own result or error, so an unknown name
+// doesn't fail the batch. A repeated target is searched once, and answers
+// are shared with /api/v1/find through the response cache both ways.
+func handleFindBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	var req BatchFindRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeInvalidParameter(w, "body", "invalid batch request: "+err.Error())
+		return
+	}
+	p, field, msg := req.params()
+	if field != "" {
+		writeInvalidParameter(w, field, msg)
+		return
+	}
+
+	// Every target is searched on the same snapshot
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	p.useActiveSnapshot()
+
+	extendDeadlines(w, p.Timeout) // the batch may run longer than -write-timeout
+	ctx, cancel := context.WithTimeout(r.Context(), p.Timeout)
+	defer cancel()
+
+	t0 := time.Now()
+	logger := recipeFinder.Logger(r.Context())
+	resp := BatchFindResponse{Snapshot: p.Version, Results: make([]BatchFindItem, len(req.Targets))}
+
+	// ---------- resolve names, searching each element once ----------
+	names := make([]string, len(req.Targets))
+	first := make(map[string]int) // canonical name → index of its first occurrence
+	var jobs []int
+	for i, target := range req.Targets {
+		resp.Results[i].Target = target
+		name, ok := recipeFinder.GlobalNameResolver.Resolve(target)
+		if !ok {
+			apiErr := unknownElementError(target)
+			resp.Results[i].Status = http.StatusNotFound
+			resp.Results[i].Error = &apiErr
+			continue
+		}
+		names[i] = name
+		if _, dup := first[name]; !dup {
+			first[name] = i
+			jobs = append(jobs, i)
+		}
+	}
+
+	// ---------- search on a bounded pool ----------
+	// Each worker holds a search slot: the request's own, plus whichever of
+	// the others are free right now, so a batch never runs more searches
+	// than -max-concurrent-searches allows
+	workers := max(1, min(*batchWorkers, len(jobs)))
+	extra, release := takeSearchSlots(workers - 1)
+	defer release()
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < 1+extra; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				tp := p
+				tp.Target = names[i]
+				item := cachedFind(ctx, tp)
+				item.Target = req.Targets[i]
+				resp.Results[i] = item
+			}
+		}()
+	}
+	for _, i := range jobs {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	if r.Context().Err() != nil {
+		logger.Info("find batch abandoned: client went away", "targets", len(req.Targets))
+		return
+	}
+
+	// ---------- repeats share the first answer ----------
+	for i := range resp.Results {
+		item := &resp.Results[i]
+		if item.Result == nil && item.Error == nil {
+			shared := resp.Results[first[names[i]]]
+			shared.Target = item.Target
+			*item = shared
+		}
+		if item.Error != nil {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+	resp.DurationMs = float64(time.Since(t0).Microseconds()) / 1000.0
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+	logger.Info("find batch", "targets", len(req.Targets), "searched", len(jobs), "algorithm", p.Algorithm,
+		"succeeded", resp.Succeeded, "failed", resp.Failed, "duration_ms", resp.DurationMs)
+}
+
+// cachedFind answers one search of a batch or GraphQL query, from the
+// response cache when it can, and caches what it computes. The caller must
+// hold catalogMu.
+func cachedFind(ctx context.Context, p findParams) BatchFindItem {
+	key := findCacheKey(p, true)
+	if cached, ok := findCache.Get(key); ok {
+		var res FindResult
+		if err := json.Unmarshal(cached.body, &res); err == nil {
+			findCacheRequests.Inc("hit")
+			return BatchFindItem{Status: http.StatusOK, Result: &res, Cached: true}
+		}
+	}
+	findCacheRequests.Inc("miss")
+
+	// The budget ran out while earlier searches ran
+	if ctx.Err() != nil {
+		return BatchFindItem{Status: http.StatusGatewayTimeout, Error: &APIError{
+			Code:    codeTimeout,
+			Message: fmt.Sprintf("time budget of %dms ran out before %q was searched", p.Timeout.Milliseconds(), p.Target),
+			Details: map[string]interface{}{"target": p.Target, "timeout_ms": p.Timeout.Milliseconds()},
+		}}
+	}
+
+	res := runFind(ctx, p)
+	timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+	if !res.Precomputed { // a table lookup isn't a search
+		observeSearch(p, res.NodesVisited, resultCount(p.Target, res.Trees), timedOut)
+	}
+	if status, apiErr := checkFindResult(p, &res, timedOut); apiErr != nil {
+		return BatchFindItem{Status: status, Error: apiErr}
+	}
+
+	// Partial results depend on timing, so only complete ones are cached
+	if !res.Truncated {
+		if body, etag, err := encodeFindBody(res); err == nil {
+			findCache.Add(key, body, etag)
+		}
+	}
+	return BatchFindItem{Status: http.StatusOK, Result: &res}
+}
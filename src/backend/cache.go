@@ -0,0 +1,145 @@
+// backend/cache.go
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// findCache holds encoded /api/find responses (set up in main from the -cache-* flags)
+var findCache *responseCache
+
+// cachedResponse is one encoded response body and its ETag
+type cachedResponse struct {
+	key  string
+	body []byte
+	etag string
+}
+
+// responseCache is an LRU cache of response bodies, bounded both by the
+// number of entries and by their total size. A nil cache stores nothing.
+type responseCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	order      *list.List               // front = most recently used
+	items      map[string]*list.Element // key → element holding *cachedResponse
+}
+
+// newResponseCache returns a cache with the given limits, or nil if either is 0
+func newResponseCache(maxEntries, maxBytes int) *responseCache {
+	if maxEntries <= 0 || maxBytes <= 0 {
+		return nil
+	}
+	return &responseCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached response for key and marks it recently used
+func (c *responseCache) Get(key string) (*cachedResponse, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*cachedResponse), true
+}
+
+// Add stores body under key, evicting the least recently used entries to
+// stay within the limits. Bodies larger than the whole cache aren't stored.
+func (c *responseCache) Add(key string, body []byte, etag string) {
+	if c == nil || len(body) > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	c.items[key] = c.order.PushFront(&cachedResponse{key: key, body: body, etag: etag})
+	c.bytes += len(body)
+
+	for c.order.Len() > c.maxEntries || c.bytes > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+// Purge drops every entry (the catalog changed)
+func (c *responseCache) Purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	c.bytes = 0
+}
+
+// remove deletes one entry; the caller holds mu
+func (c *responseCache) remove(el *list.Element) {
+	entry := c.order.Remove(el).(*cachedResponse)
+	delete(c.items, entry.key)
+	c.bytes -= len(entry.body)
+}
+
+// findCacheKey identifies a /api/find result: the API version and snapshot
+// it was computed on and every parameter that changes the body. The time
+// budget is left out because truncated results are never cached.
+// The caller must hold catalogMu.
+func findCacheKey(p findParams, v1 bool) string {
+	api := "legacy"
+	if v1 {
+		api = "v1"
+	}
+	return fmt.Sprintf("%s|%s|%s|%s|%s|multi=%t|maxPaths=%d|steps=%s|deterministic=%t",
+		api, catalogDataset, p.Version, p.Target, p.Algorithm, p.Multi, p.MaxPaths, p.Steps, p.Deterministic)
+}
+
+// bodyETag returns a strong ETag for a response body
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// writeCacheable sends a JSON body with its ETag, or 304 Not Modified when
+// the client already holds that version (If-None-Match)
+func writeCacheable(w http.ResponseWriter, r *http.Request, body []byte, etag string) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache") // may be stored, but revalidate first
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
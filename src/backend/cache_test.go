@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newResponseCache(2, 100)
+	c.Add("a", []byte("aaaa"), `"a"`)
+	c.Add("b", []byte("bbbb"), `"b"`)
+	c.Get("a") // b is now the least recently used
+	c.Add("c", []byte("cccc"), `"c"`)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("%s cached = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestResponseCacheBoundsBytes(t *testing.T) {
+	c := newResponseCache(10, 10)
+	c.Add("a", []byte("aaaa"), `"a"`)
+	c.Add("b", []byte("bbbb"), `"b"`)
+	c.Add("c", []byte("cccc"), `"c"`) // 12 bytes: a goes
+	if _, ok := c.Get("a"); ok || c.bytes != 8 {
+		t.Errorf("a cached = %v, %d bytes, want it evicted and 8 bytes", ok, c.bytes)
+	}
+
+	// Replacing an entry counts its new size only
+	c.Add("b", []byte("bb"), `"b2"`)
+	if got, ok := c.Get("b"); !ok || got.etag != `"b2"` || c.bytes != 6 {
+		t.Errorf("b = %+v, %d bytes, want the new body and 6 bytes", got, c.bytes)
+	}
+
+	// A body larger than the whole cache isn't stored and evicts nothing
+	c.Add("big", make([]byte, 11), `"big"`)
+	if _, ok := c.Get("big"); ok || c.order.Len() != 2 {
+		t.Errorf("big cached = %v, %d entries, want it skipped and 2 entries", ok, c.order.Len())
+	}
+
+	c.Purge()
+	if _, ok := c.Get("b"); ok || c.bytes != 0 || c.order.Len() != 0 {
+		t.Errorf("after Purge: %d entries, %d bytes", c.order.Len(), c.bytes)
+	}
+}
+
+func TestResponseCacheDisabled(t *testing.T) {
+	c := newResponseCache(0, 100)
+	if c != nil {
+		t.Fatal("a cache without entries should be nil")
+	}
+	c.Add("a", []byte("a"), `"a"`) // a nil cache stores nothing
+	if _, ok := c.Get("a"); ok {
+		t.Error("nil cache returned an entry")
+	}
+	c.Purge()
+}
+
+func TestETagMatches(t *testing.T) {
+	cases := []struct {
+		header string
+		want   bool
+	}{
+		{``, false},
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"x", "abc"`, true},
+		{`"abcd"`, false},
+		{`*`, true},
+	}
+	for _, c := range cases {
+		if got := etagMatches(c.header, `"abc"`); got != c.want {
+			t.Errorf("If-None-Match %q: %v, want %v", c.header, got, c.want)
+		}
+	}
+}
+
+func TestFindServesFromCache(t *testing.T) {
+	useTestCatalog(t)
+
+	find := func(etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/find?target=Stone&maxPaths=2&steps=none", nil)
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		handleFind(w, r)
+		return w
+	}
+
+	first := find("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || first.Header().Get("X-Cache") != "MISS" || etag == "" {
+		t.Fatalf("first: status %d, X-Cache %q, ETag %q", first.Code, first.Header().Get("X-Cache"), etag)
+	}
+
+	again := find("")
+	if again.Header().Get("X-Cache") != "HIT" || again.Header().Get("ETag") != etag || again.Body.String() != first.Body.String() {
+		t.Errorf("again: X-Cache %q, ETag %q, want the cached body", again.Header().Get("X-Cache"), again.Header().Get("ETag"))
+	}
+
+	// The client already holds this version
+	if w := find(etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("If-None-Match: status %d, body %q, want 304 without body", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,132 @@
+// backend/catalog.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// catalogDataset names the data set the catalog is scraped from
+const catalogDataset = "little-alchemy-2"
+
+// -----------------------------------------------------------------------------
+// Active catalog state
+// -----------------------------------------------------------------------------
+var (
+	// catalogMu guards rawJSON and the catalog globals in recipeFinder.
+	// Searches hold the read lock, swapping in a new catalog takes the write lock.
+	catalogMu sync.RWMutex
+	rawJSON   []byte // recipe.json contents served by /api/recipes
+
+	// catalogVersion identifies the active snapshot (hash of recipe.json)
+	catalogVersion string
+	// catalogScrapedAt is when the active snapshot was scraped
+	catalogScrapedAt time.Time
+
+	// scrapeMu makes sure only one scrape (manual or scheduled) runs at a time
+	scrapeMu sync.Mutex
+
+	// elementAliases are the alternative names accepted for element lookups
+	elementAliases = recipeFinder.DefaultAliases
+)
+
+// installCatalog sorts a freshly scraped catalog, writes it to recipe.json
+// and makes it the active catalog.
+func installCatalog(catalog recipeFinder.Catalog) error {
+	sortCatalogTiers(&catalog)
+
+	raw, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode catalog: %w", err)
+	}
+	os.MkdirAll(jsonDir, 0o755) // ensure directory exists
+	if err := os.WriteFile(jsonFile, raw, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", jsonFile, err)
+	}
+
+	activateCatalog(catalog, raw, time.Now())
+	return nil
+}
+
+// activateCatalog swaps in a catalog scraped at scrapedAt: re-initializes
+// the tier cache and rebuilds the indexed graph used by every search.
+func activateCatalog(catalog recipeFinder.Catalog, raw []byte, scrapedAt time.Time) {
+	// Sort tiers in catalog - "Starting" first, then numeric tiers in order
+	sortCatalogTiers(&catalog)
+
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	recipeFinder.GlobalCatalog = catalog
+	rawJSON = raw
+	catalogVersion = snapshotVersion(raw)
+	catalogScrapedAt = scrapedAt
+	recipeFinder.InitElementTiers(catalog)
+	recipeFinder.GlobalIndexedGraph = recipeFinder.BuildIndexedGraph(catalog)
+	recipeFinder.BuildReverseIndex(recipeFinder.GlobalIndexedGraph) // memos shared by every search on this snapshot
+	recipeFinder.GlobalNameResolver = recipeFinder.BuildNameResolver(recipeFinder.GlobalIndexedGraph, elementAliases)
+	recipeFinder.GlobalElementIndex = recipeFinder.BuildElementIndex(catalog)
+
+	// Cached search results belong to the old snapshot
+	findCache.Purge()
+
+	// Precompute the single-recipe answers of the new snapshot
+	startWarmup(catalogVersion, recipeFinder.GlobalIndexedGraph)
+}
+
+// snapshotVersion derives a short, stable version string from recipe.json
+func snapshotVersion(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:8])
+}
+
+// loadAliases merges the optional json/aliases.json ({"alias": "Element"})
+// into the default alias table
+func loadAliases() {
+	raw, err := os.ReadFile(aliasFile)
+	if err != nil {
+		return // aliases.json is optional
+	}
+	var extra map[string]string
+	if err := json.Unmarshal(raw, &extra); err != nil {
+		slog.Warn("ignoring invalid aliases file", "path", aliasFile, "error", err)
+		return
+	}
+
+	merged := make(map[string]string, len(elementAliases)+len(extra))
+	for k, v := range elementAliases {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	elementAliases = merged
+	slog.Info("loaded aliases", "count", len(extra), "path", aliasFile)
+}
+
+// scrapeLimits builds the scrape minimums from the command line flags
+func scrapeLimits() recipeFinder.ScrapeLimits {
+	return recipeFinder.ScrapeLimits{
+		MinTiers:    *scrapeMinTiers,
+		MinElements: *scrapeMinElements,
+		MinRecipes:  *scrapeMinRecipes,
+	}
+}
+
+// logScrapeReport prints the size of a scrape and every parse warning it collected
+func logScrapeReport(report recipeFinder.ScrapeReport) {
+	slog.Info("scrape finished", "tiers", report.Tiers, "elements", report.Elements,
+		"recipes", report.Recipes, "warnings", len(report.Warnings))
+	for _, w := range report.Warnings {
+		slog.Warn("scrape warning", "kind", w.Kind, "tier", w.Tier, "element", w.Element,
+			"row", w.Row, "message", w.Message)
+	}
+}
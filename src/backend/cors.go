@@ -0,0 +1,89 @@
+// backend/cors.go
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// corsMaxAge is how long browsers may cache a preflight answer
+const corsMaxAge = 600 // seconds
+
+// corsExposedHeaders are the response headers scripts on other origins may read
+const corsExposedHeaders = "ETag, X-Cache, X-Request-ID, Retry-After"
+
+// splitList parses a comma separated flag value
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// corsAllowed reports whether origin may use the API (-cors-origins, "*" = any)
+func corsAllowed(origin string) bool {
+	for _, o := range splitList(*corsOrigins) {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// withCORS adds the CORS headers to every response and answers preflight
+// requests itself, from -cors-origins, -cors-methods and -cors-headers
+func withCORS(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		w.Header().Add("Vary", "Origin")
+		if origin == "" || !corsAllowed(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusNoContent) // no CORS headers: the browser blocks the request
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if containsFold(splitList(*corsOrigins), "*") {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if !preflight {
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		// ---------- preflight ----------
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		method := r.Header.Get("Access-Control-Request-Method")
+		if !containsFold(splitList(*corsMethods), method) {
+			w.WriteHeader(http.StatusNoContent) // method not allowed: leave out Allow-Methods
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(splitList(*corsMethods), ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(splitList(*corsHeaders), ", "))
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
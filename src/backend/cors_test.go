@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveCORS sends a request from origin through withCORS; the wrapped
+// handler answers 200 "ok"
+func serveCORS(method, origin string, header map[string]string) *httptest.ResponseRecorder {
+	h := withCORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	r := httptest.NewRequest(method, "/api/v1/find", nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestCORSOrigins(t *testing.T) {
+	defer func(o string) { *corsOrigins = o }(*corsOrigins)
+	*corsOrigins = "https://app.example, http://localhost:3000"
+
+	// An allowed origin is echoed back, matched case-insensitively
+	w := serveCORS(http.MethodGet, "HTTP://LOCALHOST:3000", nil)
+	if w.Body.String() != "ok" || w.Header().Get("Access-Control-Allow-Origin") != "HTTP://LOCALHOST:3000" ||
+		w.Header().Get("Access-Control-Expose-Headers") != corsExposedHeaders || w.Header().Get("Vary") != "Origin" {
+		t.Errorf("allowed origin: headers %v, body %q", w.Header(), w.Body)
+	}
+
+	// Other origins and same-origin requests are served without CORS headers
+	for _, origin := range []string{"https://evil.example", ""} {
+		w := serveCORS(http.MethodGet, origin, nil)
+		if w.Body.String() != "ok" || w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("origin %q: headers %v, body %q", origin, w.Header(), w.Body)
+		}
+	}
+
+	*corsOrigins = "*"
+	if w := serveCORS(http.MethodGet, "https://any.example", nil); w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("*: Allow-Origin %q", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	*corsOrigins = ""
+	if w := serveCORS(http.MethodGet, "https://any.example", nil); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("no origins: Allow-Origin %q", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	defer func(o, m, h string) { *corsOrigins, *corsMethods, *corsHeaders = o, m, h }(*corsOrigins, *corsMethods, *corsHeaders)
+	*corsOrigins, *corsMethods, *corsHeaders = "https://app.example", "GET,POST", "Content-Type"
+
+	// The preflight is answered without reaching the handler
+	w := serveCORS(http.MethodOptions, "https://app.example", map[string]string{"Access-Control-Request-Method": "POST"})
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 ||
+		w.Header().Get("Access-Control-Allow-Origin") != "https://app.example" ||
+		w.Header().Get("Access-Control-Allow-Methods") != "GET, POST" ||
+		w.Header().Get("Access-Control-Allow-Headers") != "Content-Type" ||
+		w.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("preflight: status %d, headers %v, body %q", w.Code, w.Header(), w.Body)
+	}
+
+	// A method that isn't allowed gets no Allow-Methods, so the browser refuses it
+	w = serveCORS(http.MethodOptions, "https://app.example", map[string]string{"Access-Control-Request-Method": "DELETE"})
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Methods") != "" {
+		t.Errorf("DELETE preflight: status %d, headers %v", w.Code, w.Header())
+	}
+
+	// So does an origin that isn't allowed
+	w = serveCORS(http.MethodOptions, "https://evil.example", map[string]string{"Access-Control-Request-Method": "GET"})
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "" || w.Body.Len() != 0 {
+		t.Errorf("preflight from another origin: status %d, headers %v", w.Code, w.Header())
+	}
+
+	// An OPTIONS request that isn't a preflight reaches the handler
+	if w := serveCORS(http.MethodOptions, "https://app.example", nil); w.Body.String() != "ok" {
+		t.Errorf("plain OPTIONS: status %d, body %q", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,83 @@
+// backend/elements.go
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// ElementMatch is one autocomplete result of /api/v1/elements
+type ElementMatch struct {
+	Name        string `json:"name"`
+	Tier        string `json:"tier"`
+	SVGPath     string `json:"svg_path"`
+	RecipeCount int    `json:"recipe_count"`
+	Match       string `json:"match"` // exact | prefix | substring | fuzzy
+	Score       int    `json:"score"` // lower is better
+}
+
+// handleElements serves /api/v1/elements?q=Name&limit=10 — ranked element
+// matches for autocomplete. Without q every element is returned.
+func handleElements(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+
+	// limit (default 10 while typing, everything for an empty query)
+	limit := 10
+	if q == "" {
+		limit = 0
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeInvalidParameter(w, "limit", "limit must be a non-negative integer")
+			return
+		}
+		limit = n
+	}
+
+	catalogMu.RLock()
+	matches := recipeFinder.GlobalNameResolver.Search(q, limit)
+	elements := make([]ElementMatch, 0, len(matches))
+	for _, m := range matches {
+		meta := recipeFinder.GlobalElementIndex[m.Name]
+		elements = append(elements, ElementMatch{
+			Name:        m.Name,
+			Tier:        meta.Tier,
+			SVGPath:     meta.SVGPath,
+			RecipeCount: len(meta.Recipes),
+			Match:       m.Match,
+			Score:       m.Score,
+		})
+	}
+	catalogMu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ElementsResponse{Query: q, Elements: elements})
+}
+
+// handleElementDetail serves /api/v1/element/{name} — tier, recipes, usages,
+// minimal depth, recipe-tree count and icon of a single element
+func handleElementDetail(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimPrefix(apiPath(r), "/element/")
+	if query == "" {
+		writeInvalidParameter(w, "name", "missing element name: "+r.URL.Path+"{name}")
+		return
+	}
+
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	name, ok := recipeFinder.GlobalNameResolver.Resolve(query)
+	if !ok {
+		writeUnknownElement(w, query)
+		return
+	}
+	detail, _ := recipeFinder.GetElementDetail(name, recipeFinder.GlobalIndexedGraph)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestElementsAutocomplete(t *testing.T) {
+	useTestCatalog(t)
+
+	search := func(query string) (int, ElementsResponse) {
+		w := httptest.NewRecorder()
+		handleElements(w, httptest.NewRequest(http.MethodGet, "/api/v1/elements?"+query, nil))
+		var resp ElementsResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	status, resp := search("q=br")
+	if status != http.StatusOK || resp.Query != "br" || len(resp.Elements) == 0 {
+		t.Fatalf("q=br: status %d, %+v", status, resp)
+	}
+	if got := resp.Elements[0]; got.Name != "Brick" || got.Match != "prefix" || got.Tier != "2" || got.RecipeCount != 1 {
+		t.Errorf("q=br: first match %+v", got)
+	}
+
+	// Without a query every element is listed, unless limited
+	if _, resp := search(""); len(resp.Elements) != 9 {
+		t.Errorf("no query: %d elements, want 9", len(resp.Elements))
+	}
+	if _, resp := search("limit=3"); len(resp.Elements) != 3 {
+		t.Errorf("limit=3: %d elements", len(resp.Elements))
+	}
+
+	for _, bad := range []string{"limit=-1", "limit=ten"} {
+		if status, _ := search("q=br&" + bad); status != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", bad, status)
+		}
+	}
+}
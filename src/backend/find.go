@@ -0,0 +1,384 @@
+// backend/find.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// FindResponse is the body of a successful /api/find response, the
+// unversioned alias of /api/v1/find (see FindResult)
+type FindResponse struct {
+	Tree         interface{} `json:"tree"`
+	DurationMs   float64     `json:"duration_ms"`
+	Algorithm    string      `json:"algorithm"`
+	NodesVisited int         `json:"nodes_visited"`
+	SearchSteps  interface{} `json:"search_steps,omitempty"` // Use interface{} for flexibility
+
+	// Truncated is set when the time budget ran out; Tree then holds partial results
+	Truncated bool      `json:"truncated,omitempty"`
+	Warning   *APIError `json:"warning,omitempty"`
+
+	// Precomputed is set when the answer came from the warm-up recipe table
+	Precomputed bool `json:"precomputed,omitempty"`
+}
+
+// maxDFSSteps caps the DFS steps returned in search_steps; the
+// multi-path DFS easily visits millions of nodes
+const maxDFSSteps = 5000
+
+// findParams are the validated query parameters of /api/find
+type findParams struct {
+	Target    string        // canonical element name
+	MaxPaths  int           // number of recipes wanted in multi mode
+	Multi     bool          // multiple recipes or a single one
+	Algorithm string        // bfs | dfs | bidirectional
+	Timeout   time.Duration // time budget for the search
+	Steps     string        // search step format: full | delta | none
+
+	// Deterministic trades some speed for identical results on every run
+	Deterministic bool
+
+	// Graph is the snapshot Target was resolved on and Version its
+	// catalogVersion. The search runs on Graph, so a caller that has let go
+	// of catalogMu still searches one consistent catalog (a graph is never
+	// mutated in place).
+	Graph   recipeFinder.IndexedGraph
+	Version string
+}
+
+// useActiveSnapshot points p at the active catalog. The caller must hold catalogMu.
+func (p *findParams) useActiveSnapshot() {
+	p.Graph, p.Version = recipeFinder.GlobalIndexedGraph, catalogVersion
+}
+
+// parseFindParams validates the /api/find query. On failure it has already
+// written the error response and returns false.
+// The caller must hold catalogMu (the target is resolved against the active graph).
+func parseFindParams(w http.ResponseWriter, r *http.Request) (findParams, bool) {
+	q := r.URL.Query()
+	p := findParams{MaxPaths: 5, Multi: true, Algorithm: "bfs", Timeout: *searchTimeout, Steps: "full"}
+
+	// ---------- target ----------
+	target := q.Get("target")
+	if target == "" {
+		writeInvalidParameter(w, "target", "missing ?target=")
+		return p, false
+	}
+	// Resolve to the canonical wiki name (case, spacing, aliases)
+	name, ok := recipeFinder.GlobalNameResolver.Resolve(target)
+	if !ok {
+		writeUnknownElement(w, target)
+		return p, false
+	}
+	p.Target = name
+	p.useActiveSnapshot()
+
+	// ---------- maxPaths (default 5) ----------
+	if v := q.Get("maxPaths"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			writeInvalidParameter(w, "maxPaths", "maxPaths must be a positive integer")
+			return p, false
+		}
+		p.MaxPaths = int(n)
+	}
+
+	// ---------- multi=true/false ----------
+	if v := q.Get("multi"); v != "" {
+		if v != "true" && v != "false" {
+			writeInvalidParameter(w, "multi", "multi must be true or false")
+			return p, false
+		}
+		p.Multi = v == "true"
+	}
+
+	// ---------- algorithm=bfs|dfs|bidirectional ----------
+	switch algo := q.Get("algorithm"); algo {
+	case "":
+	case "bfs", "dfs":
+		p.Algorithm = algo
+	case "bidirectional":
+		// Single recipe only: implied unless the caller asked for multi=true
+		if q.Get("multi") == "true" {
+			writeInvalidParameter(w, "multi", "bidirectional search only supports multi=false")
+			return p, false
+		}
+		p.Algorithm, p.Multi = algo, false
+	default:
+		writeInvalidParameter(w, "algorithm", "algorithm must be bfs, dfs or bidirectional")
+		return p, false
+	}
+
+	// ---------- timeoutMs (default -search-timeout, capped) ----------
+	if v := q.Get("timeoutMs"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeInvalidParameter(w, "timeoutMs", "timeoutMs must be a positive integer")
+			return p, false
+		}
+		p.Timeout = time.Duration(n) * time.Millisecond
+	}
+	if p.Timeout > *maxSearchTimeout {
+		p.Timeout = *maxSearchTimeout
+	}
+
+	// ---------- deterministic=true/false ----------
+	if v := q.Get("deterministic"); v != "" {
+		if v != "true" && v != "false" {
+			writeInvalidParameter(w, "deterministic", "deterministic must be true or false")
+			return p, false
+		}
+		p.Deterministic = v == "true"
+	}
+
+	// ---------- steps=full|delta|none ----------
+	switch v := q.Get("steps"); v {
+	case "":
+	case "full", "delta", "none":
+		p.Steps = v
+	default:
+		writeInvalidParameter(w, "steps", "steps must be full, delta or none")
+		return p, false
+	}
+
+	return p, true
+}
+
+// searchOptions are the multi-path search options for a request
+func searchOptions(p findParams) recipeFinder.MultiPathOptions {
+	return recipeFinder.MultiPathOptions{Deterministic: p.Deterministic, Workers: *searchWorkers}
+}
+
+// handleFind serves /api/v1/find?target=Name&maxPaths=5&multi=true&algorithm=bfs&timeoutMs=10000&steps=full&deterministic=false
+func handleFind(w http.ResponseWriter, r *http.Request) {
+	// Keep the catalog steady while searching (a scrape may swap it)
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	p, ok := parseFindParams(w, r)
+	if !ok {
+		return
+	}
+	extendDeadlines(w, p.Timeout) // the search may run longer than -write-timeout
+
+	// Same query on the same snapshot: answer from the cache
+	v1 := isV1(r)
+	key := findCacheKey(p, v1)
+	logger := recipeFinder.Logger(r.Context())
+	if cached, ok := findCache.Get(key); ok {
+		findCacheRequests.Inc("hit")
+		logger.Debug("find served from cache", "target", p.Target, "algorithm", p.Algorithm)
+		w.Header().Set("X-Cache", "HIT")
+		writeCacheable(w, r, cached.body, cached.etag)
+		return
+	}
+	findCacheRequests.Inc("miss")
+
+	// The search stops when the client disconnects or the budget runs out
+	ctx, cancel := context.WithTimeout(r.Context(), p.Timeout)
+	defer cancel()
+
+	res := runFind(ctx, p)
+
+	// ---------- write response ----------
+	if r.Context().Err() != nil {
+		logger.Info("find abandoned: client went away", "target", p.Target)
+		return
+	}
+
+	timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+	if !res.Precomputed { // a table lookup isn't a search
+		observeSearch(p, res.NodesVisited, resultCount(p.Target, res.Trees), timedOut)
+	}
+	if status, apiErr := checkFindResult(p, &res, timedOut); apiErr != nil {
+		writeAPIError(w, status, *apiErr)
+		return
+	}
+
+	// The unversioned alias keeps its old body
+	var out interface{} = res
+	if !v1 {
+		out = res.legacy()
+	}
+	body, etag, err := encodeFindBody(out)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, codeInternal, "failed to encode response", nil)
+		return
+	}
+
+	// Partial results depend on timing, so only complete ones are cached
+	if !res.Truncated {
+		findCache.Add(key, body, etag)
+	}
+	w.Header().Set("X-Cache", "MISS")
+	writeCacheable(w, r, body, etag)
+	logger.Info("find", "target", p.Target, "algorithm", p.Algorithm, "multi", p.Multi,
+		"nodes_visited", res.NodesVisited, "duration_ms", res.DurationMs,
+		"truncated", res.Truncated, "precomputed", res.Precomputed)
+}
+
+// checkFindResult settles a finished search: without a recipe it returns
+// the error to answer with (unreachable, or timeout if the budget ran out),
+// otherwise it marks res as truncated when the budget ran out
+func checkFindResult(p findParams, res *FindResult, timedOut bool) (int, *APIError) {
+	if !hasRecipe(p.Target, res.Trees...) {
+		if timedOut {
+			return http.StatusGatewayTimeout, &APIError{
+				Code:    codeTimeout,
+				Message: fmt.Sprintf("no recipe for %q found within %dms", p.Target, p.Timeout.Milliseconds()),
+				Details: map[string]interface{}{"target": p.Target, "timeout_ms": p.Timeout.Milliseconds()},
+			}
+		}
+		e := unreachableElementError(p.Target, p.Algorithm)
+		return http.StatusNotFound, &e
+	}
+	if timedOut {
+		res.Truncated = true
+		res.Warning = &APIError{
+			Code:    codeResultTruncated,
+			Message: fmt.Sprintf("time budget of %dms exhausted, results are partial", p.Timeout.Milliseconds()),
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// encodeFindBody encodes a find response the way it is cached and sent
+func encodeFindBody(v interface{}) ([]byte, string, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, "", err
+	}
+	body = append(body, '\n')
+	return body, bodyETag(body), nil
+}
+
+// stepTracer returns the tracer recording a search in the format p.Steps asks
+// for, keeping up to limit steps (0 = no limit), and a function returning the
+// recorded steps once the search is done (nil for steps=none). Full steps copy
+// the whole search state each time; delta keeps only the changes.
+func stepTracer(p findParams, limit int) (recipeFinder.Tracer, func() *SearchSteps) {
+	g := p.Graph
+	targetID := g.NameToID[p.Target]
+	switch p.Steps {
+	case "full":
+		t := recipeFinder.NewStepTracer(g, targetID, limit)
+		return t, func() *SearchSteps {
+			return &SearchSteps{Format: stepsSnapshots, Snapshots: t.Steps()}
+		}
+	case "delta":
+		t := recipeFinder.NewDeltaTracer(g, targetID, limit, recipeFinder.DefaultKeyframeInterval)
+		return t, func() *SearchSteps {
+			delta := t.Steps()
+			return &SearchSteps{Format: stepsDelta, Delta: &delta}
+		}
+	}
+	return recipeFinder.NoopTracer{}, func() *SearchSteps { return nil }
+}
+
+// runFind runs the requested search on p.Graph.
+// When ctx is done the search returns what it has so far.
+func runFind(ctx context.Context, p findParams) FindResult {
+	g := p.Graph
+	target := p.Target
+	maxPaths := p.MaxPaths
+	opts := searchOptions(p)
+	res := FindResult{Target: target, Algorithm: p.Algorithm, Multi: p.Multi}
+	t0 := time.Now()
+
+	// ---------- choose algorithm ----------
+	switch p.Algorithm {
+	//-----------------------------------------------------------------
+	case "dfs":
+		// Trace the DFS for the visualizer, in the same step format as BFS
+		trace, searchSteps := stepTracer(p, maxDFSSteps)
+
+		if p.Multi {
+			// Get N unique paths (multi DFS)
+			effectiveMaxPaths := maxPaths * 2
+			steps, nodes := recipeFinder.RangeDFSPathsStream(ctx, target, effectiveMaxPaths, g, opts, nil, trace)
+			res.NodesVisited = nodes
+			trees := stepsToTrees(ctx, g, target, steps)
+
+			// Apply tree-based deduplication (just like in BFS)
+			if len(trees) > 0 {
+				trees = recipeFinder.DeduplicateRecipeTrees(trees)
+			}
+
+			res.Trees = trees
+		} else {
+			// Single path (single DFS)
+			rec, nodes := recipeFinder.DFSBuildTargetToBaseTrace(ctx, target, g, trace)
+			res.NodesVisited = nodes
+			res.Trees = []*recipeFinder.RecipeNode{recipeFinder.BuildTreeOn(ctx, g, target, rec)}
+		}
+		res.SearchSteps = searchSteps()
+
+	//-----------------------------------------------------------------
+	case "bidirectional": // placeholder (single recipe only, see parseFindParams)
+		prev, nodes := recipeFinder.IndexedBFSTrace(ctx, target, g, nil)
+		res.NodesVisited = nodes
+		res.Trees = []*recipeFinder.RecipeNode{recipeFinder.BuildTreeOn(ctx, g, target, prev)}
+
+	//-----------------------------------------------------------------
+	default: // bfs
+		if p.Multi {
+			// Get multiple paths using the new target→base approach
+			trace, searchSteps := stepTracer(p, recipeFinder.MaxParallelSteps)
+			completePaths, nodes := recipeFinder.ReversedMultiPathBFSParallelStream(ctx, target, g, maxPaths*5, opts, nil, trace)
+			res.NodesVisited = nodes
+			res.SearchSteps = searchSteps() // Store search steps for visualization
+
+			// Convert complete paths to trees
+			printed := map[string]bool{}
+			var trees []*recipeFinder.RecipeNode
+
+			// Each complete path is already a ProductToIngredients map
+			for _, path := range completePaths {
+				tree := recipeFinder.BuildTreeOn(ctx, g, target, path)
+
+				// Deduplicate while building
+				key, _ := json.Marshal(tree)
+				if !printed[string(key)] {
+					printed[string(key)] = true
+					trees = append(trees, tree)
+				}
+			}
+
+			if len(trees) > 0 {
+				// Apply tree-based deduplication as a final step
+				trees = recipeFinder.DeduplicateRecipeTrees(trees)
+			}
+			res.Trees = trees
+		} else {
+			// Single path BFS. Without search steps (steps=none) it's a lookup
+			// once the recipe table is warmed up (the table doesn't keep the steps).
+			var prev recipeFinder.ProductToIngredients
+			if entry, ok := lookupRecipeTable(p.Version, target); ok && p.Steps == "none" {
+				res.Trees = []*recipeFinder.RecipeNode{entry.ShortestRecipe}
+				res.NodesVisited = entry.NodesVisited
+				res.Precomputed = true
+				break
+			}
+			trace, searchSteps := stepTracer(p, 0)
+			prev, res.NodesVisited = recipeFinder.IndexedBFSTrace(ctx, target, g, trace)
+			res.SearchSteps = searchSteps()
+			res.Trees = []*recipeFinder.RecipeNode{recipeFinder.BuildTreeOn(ctx, g, target, prev)}
+		}
+	}
+
+	// A base element has no recipe: "trees": [] rather than null
+	if res.Trees == nil {
+		res.Trees = []*recipeFinder.RecipeNode{}
+	}
+
+	res.DurationMs = float64(time.Since(t0).Microseconds()) / 1000.0
+	return res
+}
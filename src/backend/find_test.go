@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestParseFindParamsBidirectional(t *testing.T) {
+	useTestCatalog(t)
+
+	cases := []struct {
+		query  string
+		status int // 0 = accepted
+	}{
+		{"target=Brick&algorithm=bidirectional", 0}, // multi defaults to true elsewhere
+		{"target=Brick&algorithm=bidirectional&multi=false", 0},
+		{"target=Brick&algorithm=bidirectional&multi=true", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		catalogMu.RLock()
+		w := httptest.NewRecorder()
+		p, ok := parseFindParams(w, httptest.NewRequest(http.MethodGet, "/api/find?"+c.query, nil))
+		catalogMu.RUnlock()
+		switch {
+		case c.status == 0 && !ok:
+			t.Errorf("%s: rejected with %d: %s", c.query, w.Code, w.Body)
+		case c.status == 0 && (p.Multi || p.Algorithm != "bidirectional"):
+			t.Errorf("%s: algorithm %s, multi %v, want bidirectional with multi=false", c.query, p.Algorithm, p.Multi)
+		case c.status != 0 && (ok || w.Code != c.status):
+			t.Errorf("%s: status %d, want %d", c.query, w.Code, c.status)
+		}
+	}
+
+	yes, no := true, false
+	for _, multi := range []*bool{nil, &no} {
+		if p, field, msg := (BatchFindRequest{Targets: []string{"Brick"}, Algorithm: "bidirectional", Multi: multi}).params(); field != "" || p.Multi {
+			t.Errorf("batch, multi %v: %s %s, multi %v", multi, field, msg, p.Multi)
+		}
+	}
+	if _, field, _ := (BatchFindRequest{Targets: []string{"Brick"}, Algorithm: "bidirectional", Multi: &yes}).params(); field != "multi" {
+		t.Errorf("batch with multi: true: accepted, want a multi error")
+	}
+}
+
+func TestFindResolvesNames(t *testing.T) {
+	useTestCatalog(t)
+
+	find := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handleFind(w, httptest.NewRequest(http.MethodGet, "/api/v1/find?steps=none&target="+url.QueryEscape(target), nil))
+		return w
+	}
+
+	// The answer names the canonical element, whatever the spelling
+	w := find("  BRICK ")
+	var res FindResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); w.Code != http.StatusOK || err != nil || res.Target != "Brick" {
+		t.Errorf("  BRICK : status %d, target %q", w.Code, res.Target)
+	}
+
+	// An unknown name gets "did you mean" suggestions
+	w = find("Brik")
+	var env struct {
+		Error struct {
+			Code    string `json:"code"`
+			Details struct {
+				Target      string   `json:"target"`
+				Suggestions []string `json:"suggestions"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &env); w.Code != http.StatusNotFound || err != nil ||
+		env.Error.Code != codeUnknownElement || env.Error.Details.Target != "Brik" ||
+		len(env.Error.Details.Suggestions) == 0 || env.Error.Details.Suggestions[0] != "Brick" {
+		t.Errorf("Brik: status %d, body %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,1340 @@
+// backend/graphql.go
+package main
+
+// A minimal GraphQL implementation, just enough for the read-only schema in
+// graphql_schema.go: queries with variables, aliases, fragments and
+// @skip/@include. Mutations, subscriptions and introspection are not
+// supported; the schema is published as SDL at /api/v1/graphql/schema.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// Limits that keep a single query from walking the whole graph over and over
+const (
+	gqlMaxDepth  = 12      // nesting levels of selection sets
+	gqlMaxFields = 20000   // fields resolved per query
+	gqlMaxBody   = 1 << 20 // size of a request body
+)
+
+// codeInvalidQuery is the extensions code of queries that don't parse or validate
+const codeInvalidQuery = "invalid_query"
+
+// -----------------------------------------------------------------------------
+// Lexer
+// -----------------------------------------------------------------------------
+
+type gqlTokenKind int
+
+const (
+	tokEOF gqlTokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type gqlToken struct {
+	kind      gqlTokenKind
+	value     string
+	line, col int
+}
+
+// gqlSyntaxError is a parse error at a position in the query
+type gqlSyntaxError struct {
+	msg       string
+	line, col int
+}
+
+func (e *gqlSyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %d:%d: %s", e.line, e.col, e.msg)
+}
+
+// gqlLex splits a query into tokens, dropping whitespace, commas and comments
+func gqlLex(src string) ([]gqlToken, error) {
+	var toks []gqlToken
+	line, col := 1, 1
+	i := 0
+	advance := func(n int) {
+		for ; n > 0; n-- {
+			if src[i] == '\n' {
+				line, col = line+1, 1
+			} else {
+				col++
+			}
+			i++
+		}
+	}
+	isNameStart := func(c byte) bool { return c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' }
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			advance(1)
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				advance(1)
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			toks = append(toks, gqlToken{tokPunct, "...", line, col})
+			advance(3)
+		case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+			toks = append(toks, gqlToken{tokPunct, string(c), line, col})
+			advance(1)
+		case isNameStart(c):
+			j := i
+			for j < len(src) && (isNameStart(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			toks = append(toks, gqlToken{tokName, src[i:j], line, col})
+			advance(j - i)
+		case c == '-' || isDigit(c):
+			j, kind := i+1, tokInt
+			for j < len(src) && isDigit(src[j]) {
+				j++
+			}
+			if j < len(src) && src[j] == '.' {
+				kind = tokFloat
+				for j++; j < len(src) && isDigit(src[j]); j++ {
+				}
+			}
+			if j < len(src) && (src[j] == 'e' || src[j] == 'E') {
+				kind = tokFloat
+				j++
+				if j < len(src) && (src[j] == '+' || src[j] == '-') {
+					j++
+				}
+				for ; j < len(src) && isDigit(src[j]); j++ {
+				}
+			}
+			toks = append(toks, gqlToken{kind, src[i:j], line, col})
+			advance(j - i)
+		case strings.HasPrefix(src[i:], `"""`):
+			end := strings.Index(src[i+3:], `"""`)
+			if end < 0 {
+				return nil, &gqlSyntaxError{"unterminated block string", line, col}
+			}
+			toks = append(toks, gqlToken{tokString, src[i+3 : i+3+end], line, col})
+			advance(end + 6)
+		case c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' && src[j] != '\n' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) || src[j] != '"' {
+				return nil, &gqlSyntaxError{"unterminated string", line, col}
+			}
+			s, err := strconv.Unquote(src[i : j+1])
+			if err != nil {
+				return nil, &gqlSyntaxError{"invalid string " + src[i:j+1], line, col}
+			}
+			toks = append(toks, gqlToken{tokString, s, line, col})
+			advance(j + 1 - i)
+		default:
+			return nil, &gqlSyntaxError{fmt.Sprintf("unexpected character %q", c), line, col}
+		}
+	}
+	return append(toks, gqlToken{tokEOF, "", line, col}), nil
+}
+
+// -----------------------------------------------------------------------------
+// Parser
+// -----------------------------------------------------------------------------
+
+// gqlDocument is a parsed query document
+type gqlDocument struct {
+	operations []*gqlOperation
+	fragments  map[string]*gqlFragment
+}
+
+type gqlOperation struct {
+	kind, name string // kind: query | mutation | subscription
+	vars       []gqlVarDef
+	sel        []*gqlSelection
+	line, col  int
+}
+
+type gqlFragment struct {
+	name, typeCond string
+	sel            []*gqlSelection
+	line, col      int
+}
+
+type gqlVarDef struct {
+	name      string
+	typ       *gqlType
+	def       interface{} // default value, nil if none
+	line, col int
+}
+
+// Kinds of selections
+const (
+	selField = iota
+	selSpread
+	selInline
+)
+
+// gqlSelection is a field, a fragment spread (name) or an inline fragment (typeCond)
+type gqlSelection struct {
+	kind        int
+	alias, name string
+	typeCond    string
+	args        []gqlArgument
+	directives  []gqlDirective
+	sel         []*gqlSelection
+	line, col   int
+}
+
+// key is the name a field is returned under
+func (s *gqlSelection) key() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+type gqlArgument struct {
+	name  string
+	value interface{} // literal or gqlVar, see parseValue
+}
+
+type gqlDirective struct {
+	name string
+	args []gqlArgument
+}
+
+// gqlVar is a reference to a variable inside a value
+type gqlVar string
+
+// gqlEnum is an enum literal (an unquoted name)
+type gqlEnum string
+
+// gqlType is a type reference: a named type, or a list of Elem, possibly non-null
+type gqlType struct {
+	Name    string
+	Elem    *gqlType
+	NonNull bool
+}
+
+func (t *gqlType) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+// named is the type without list and non-null wrappers
+func (t *gqlType) named() string {
+	for t.Elem != nil {
+		t = t.Elem
+	}
+	return t.Name
+}
+
+type gqlParser struct {
+	toks []gqlToken
+	pos  int
+}
+
+func (p *gqlParser) peek() gqlToken { return p.toks[p.pos] }
+
+func (p *gqlParser) next() gqlToken {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *gqlParser) fail(t gqlToken, format string, args ...interface{}) error {
+	return &gqlSyntaxError{fmt.Sprintf(format, args...), t.line, t.col}
+}
+
+// isPunct reports whether the next token is the punctuator v
+func (p *gqlParser) isPunct(v string) bool {
+	t := p.peek()
+	return t.kind == tokPunct && t.value == v
+}
+
+func (p *gqlParser) expect(v string) error {
+	if t := p.next(); t.kind != tokPunct || t.value != v {
+		return p.fail(t, "expected %q, found %q", v, t.value)
+	}
+	return nil
+}
+
+func (p *gqlParser) name() (gqlToken, error) {
+	t := p.next()
+	if t.kind != tokName {
+		return t, p.fail(t, "expected a name, found %q", t.value)
+	}
+	return t, nil
+}
+
+// parseGraphQL parses a query document
+func parseGraphQL(src string) (*gqlDocument, error) {
+	toks, err := gqlLex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &gqlParser{toks: toks}
+	doc := &gqlDocument{fragments: make(map[string]*gqlFragment)}
+
+	for p.peek().kind != tokEOF {
+		t := p.peek()
+		switch {
+		case t.kind == tokPunct && t.value == "{":
+			sel, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &gqlOperation{kind: "query", sel: sel, line: t.line, col: t.col})
+		case t.kind == tokName && (t.value == "query" || t.value == "mutation" || t.value == "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case t.kind == tokName && t.value == "fragment":
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.fragments[f.name]; dup {
+				return nil, p.fail(t, "fragment %q is defined twice", f.name)
+			}
+			doc.fragments[f.name] = f
+		default:
+			return nil, p.fail(t, "expected an operation or fragment, found %q", t.value)
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, &gqlSyntaxError{"the document has no operation", 1, 1}
+	}
+	return doc, nil
+}
+
+func (p *gqlParser) operation() (*gqlOperation, error) {
+	t := p.next()
+	op := &gqlOperation{kind: t.value, line: t.line, col: t.col}
+	if p.peek().kind == tokName {
+		op.name = p.next().value
+	}
+	if p.isPunct("(") {
+		p.next()
+		for !p.isPunct(")") {
+			v, err := p.varDef()
+			if err != nil {
+				return nil, err
+			}
+			op.vars = append(op.vars, v)
+		}
+		p.next()
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sel, err := p.selectionSet()
+	op.sel = sel
+	return op, err
+}
+
+func (p *gqlParser) varDef() (gqlVarDef, error) {
+	t := p.peek()
+	if err := p.expect("$"); err != nil {
+		return gqlVarDef{}, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return gqlVarDef{}, err
+	}
+	if err := p.expect(":"); err != nil {
+		return gqlVarDef{}, err
+	}
+	typ, err := p.typeRef()
+	if err != nil {
+		return gqlVarDef{}, err
+	}
+	v := gqlVarDef{name: name.value, typ: typ, line: t.line, col: t.col}
+	if p.isPunct("=") {
+		p.next()
+		if v.def, err = p.value(true); err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+func (p *gqlParser) typeRef() (*gqlType, error) {
+	var t *gqlType
+	if p.isPunct("[") {
+		p.next()
+		elem, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		t = &gqlType{Elem: elem}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		t = &gqlType{Name: name.value}
+	}
+	if p.isPunct("!") {
+		p.next()
+		t.NonNull = true
+	}
+	return t, nil
+}
+
+func (p *gqlParser) fragment() (*gqlFragment, error) {
+	t := p.next() // "fragment"
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if on := p.next(); on.kind != tokName || on.value != "on" {
+		return nil, p.fail(on, `expected "on", found %q`, on.value)
+	}
+	typeCond, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sel, err := p.selectionSet()
+	return &gqlFragment{name: name.value, typeCond: typeCond.value, sel: sel, line: t.line, col: t.col}, err
+}
+
+func (p *gqlParser) selectionSet() ([]*gqlSelection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []*gqlSelection
+	for !p.isPunct("}") {
+		if p.peek().kind == tokEOF {
+			return nil, p.fail(p.peek(), `expected "}"`)
+		}
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, s)
+	}
+	p.next()
+	if len(sels) == 0 {
+		t := p.toks[p.pos-1]
+		return nil, p.fail(t, "empty selection set")
+	}
+	return sels, nil
+}
+
+func (p *gqlParser) selection() (*gqlSelection, error) {
+	t := p.peek()
+	s := &gqlSelection{line: t.line, col: t.col}
+	var err error
+
+	if p.isPunct("...") {
+		p.next()
+		if n := p.peek(); n.kind == tokName && n.value != "on" {
+			p.next()
+			s.kind, s.name = selSpread, n.value
+			s.directives, err = p.directives()
+			return s, err
+		}
+		s.kind = selInline
+		if n := p.peek(); n.kind == tokName && n.value == "on" {
+			p.next()
+			cond, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			s.typeCond = cond.value
+		}
+		if s.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		s.sel, err = p.selectionSet()
+		return s, err
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	s.kind, s.name = selField, name.value
+	if p.isPunct(":") {
+		p.next()
+		field, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		s.alias, s.name = name.value, field.value
+	}
+	if s.args, err = p.arguments(); err != nil {
+		return nil, err
+	}
+	if s.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.isPunct("{") {
+		s.sel, err = p.selectionSet()
+	}
+	return s, err
+}
+
+func (p *gqlParser) arguments() ([]gqlArgument, error) {
+	if !p.isPunct("(") {
+		return nil, nil
+	}
+	p.next()
+	var args []gqlArgument
+	for !p.isPunct(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.value(false)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, gqlArgument{name.value, v})
+	}
+	p.next()
+	return args, nil
+}
+
+func (p *gqlParser) directives() ([]gqlDirective, error) {
+	var ds []gqlDirective
+	for p.isPunct("@") {
+		p.next()
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, gqlDirective{name.value, args})
+	}
+	return ds, nil
+}
+
+// value parses a value literal into Go values: string, int, float64, bool,
+// nil, gqlEnum, []interface{}, map[string]interface{} and gqlVar (unless
+// const, as in variable defaults)
+func (p *gqlParser) value(isConst bool) (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return t.value, nil
+	case tokInt:
+		n, err := strconv.Atoi(t.value)
+		if err != nil {
+			return nil, p.fail(t, "invalid integer %s", t.value)
+		}
+		return n, nil
+	case tokFloat:
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, p.fail(t, "invalid number %s", t.value)
+		}
+		return f, nil
+	case tokName:
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return gqlEnum(t.value), nil
+	case tokPunct:
+		switch t.value {
+		case "$":
+			if isConst {
+				return nil, p.fail(t, "variables are not allowed here")
+			}
+			name, err := p.name()
+			return gqlVar(name.value), err
+		case "[":
+			list := []interface{}{}
+			for !p.isPunct("]") {
+				if p.peek().kind == tokEOF {
+					return nil, p.fail(p.peek(), `expected "]"`)
+				}
+				v, err := p.value(isConst)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			p.next()
+			return list, nil
+		case "{":
+			obj := map[string]interface{}{}
+			for !p.isPunct("}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if obj[name.value], err = p.value(isConst); err != nil {
+					return nil, err
+				}
+			}
+			p.next()
+			return obj, nil
+		}
+	}
+	return nil, p.fail(t, "expected a value, found %q", t.value)
+}
+
+// -----------------------------------------------------------------------------
+// Schema description
+// -----------------------------------------------------------------------------
+
+// gqlResolver computes a field of parent; args are already coerced to the
+// argument types. An *APIError is reported with its code in the extensions.
+type gqlResolver func(ex *gqlExec, parent interface{}, args map[string]interface{}) (interface{}, error)
+
+// gqlFieldDef is one field of an object type
+type gqlFieldDef struct {
+	Name    string
+	Type    string // GraphQL type, e.g. "[Element!]!"
+	Doc     string
+	Args    []gqlArgDef
+	Resolve gqlResolver
+	Search  bool // runs a search: a query may select -batch-max-targets of them, none inside a list
+
+	typ *gqlType // parsed Type
+}
+
+// gqlArgDef is one argument of a field
+type gqlArgDef struct {
+	Name    string
+	Type    string
+	Default interface{} // nil for none
+
+	typ *gqlType
+}
+
+// gqlObjectType is an object type of the schema
+type gqlObjectType struct {
+	Name   string
+	Doc    string
+	Fields []*gqlFieldDef
+}
+
+func (o *gqlObjectType) field(name string) *gqlFieldDef {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// gqlScalar is a leaf type of the schema
+type gqlScalar struct {
+	Name, Doc string
+	builtin   bool
+}
+
+// gqlSchemaDef is a complete schema; the query root is the type "Query"
+type gqlSchemaDef struct {
+	scalars []gqlScalar
+	types   []*gqlObjectType
+	byName  map[string]*gqlObjectType
+}
+
+// newGQLSchema indexes the types and parses every type reference. A broken
+// reference is a programming error, so it panics.
+func newGQLSchema(scalars []gqlScalar, types ...*gqlObjectType) *gqlSchemaDef {
+	s := &gqlSchemaDef{scalars: scalars, types: types, byName: make(map[string]*gqlObjectType)}
+	for _, t := range types {
+		s.byName[t.Name] = t
+	}
+	parse := func(ref string) *gqlType {
+		toks, err := gqlLex(ref)
+		if err != nil {
+			panic(err)
+		}
+		typ, err := (&gqlParser{toks: toks}).typeRef()
+		if err != nil || !s.known(typ.named()) {
+			panic("graphql schema: bad type " + ref)
+		}
+		return typ
+	}
+	for _, t := range types {
+		for _, f := range t.Fields {
+			f.typ = parse(f.Type)
+			for i := range f.Args {
+				f.Args[i].typ = parse(f.Args[i].Type)
+			}
+		}
+	}
+	return s
+}
+
+func (s *gqlSchemaDef) known(name string) bool {
+	return s.isScalar(name) || s.byName[name] != nil
+}
+
+func (s *gqlSchemaDef) isScalar(name string) bool {
+	for _, sc := range s.scalars {
+		if sc.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SDL prints the schema in the GraphQL schema definition language
+func (s *gqlSchemaDef) SDL() string {
+	var b strings.Builder
+	desc := func(indent, doc string) {
+		if doc != "" {
+			fmt.Fprintf(&b, "%s%s\n", indent, strconv.Quote(doc))
+		}
+	}
+	for _, sc := range s.scalars {
+		if !sc.builtin {
+			desc("", sc.Doc)
+			fmt.Fprintf(&b, "scalar %s\n\n", sc.Name)
+		}
+	}
+	for _, t := range s.types {
+		desc("", t.Doc)
+		fmt.Fprintf(&b, "type %s {\n", t.Name)
+		for _, f := range t.Fields {
+			desc("  ", f.Doc)
+			fmt.Fprintf(&b, "  %s", f.Name)
+			if len(f.Args) > 0 {
+				args := make([]string, len(f.Args))
+				for i, a := range f.Args {
+					args[i] = a.Name + ": " + a.Type
+					if a.Default != nil {
+						def, _ := json.Marshal(a.Default)
+						args[i] += " = " + string(def)
+					}
+				}
+				fmt.Fprintf(&b, "(%s)", strings.Join(args, ", "))
+			}
+			fmt.Fprintf(&b, ": %s\n", f.Type)
+		}
+		b.WriteString("}\n\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// -----------------------------------------------------------------------------
+// Validation
+// -----------------------------------------------------------------------------
+
+// gqlError is one entry of the "errors" list of a response
+type gqlError struct {
+	Message    string                 `json:"message"`
+	Locations  []gqlLocation          `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type gqlLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// invalidQuery is a parse or validation error at line:col
+func invalidQuery(line, col int, format string, args ...interface{}) gqlError {
+	return gqlError{
+		Message:    fmt.Sprintf(format, args...),
+		Locations:  []gqlLocation{{line, col}},
+		Extensions: map[string]interface{}{"code": codeInvalidQuery},
+	}
+}
+
+// gqlValidator checks a document against the schema before anything runs
+type gqlValidator struct {
+	schema *gqlSchemaDef
+	doc    *gqlDocument
+	vars   map[string]bool
+	errs   []gqlError
+
+	searches int // Search fields selected so far
+}
+
+// validate picks the operation to run and checks it. It returns the
+// operation, or the errors to answer with.
+func (s *gqlSchemaDef) validate(doc *gqlDocument, opName string) (*gqlOperation, []gqlError) {
+	var op *gqlOperation
+	for _, o := range doc.operations {
+		if opName == "" && len(doc.operations) > 1 {
+			return nil, []gqlError{invalidQuery(o.line, o.col, "operationName is required when the document has several operations")}
+		}
+		if opName == "" || o.name == opName {
+			op = o
+			break
+		}
+	}
+	if op == nil {
+		return nil, []gqlError{invalidQuery(1, 1, "unknown operation %q", opName)}
+	}
+	if op.kind != "query" {
+		return nil, []gqlError{invalidQuery(op.line, op.col, "only queries are supported, not %s", op.kind)}
+	}
+
+	v := &gqlValidator{schema: s, doc: doc, vars: make(map[string]bool)}
+	for _, d := range op.vars {
+		if !s.isScalar(d.typ.named()) {
+			v.errs = append(v.errs, invalidQuery(d.line, d.col, "variable $%s has unknown input type %s", d.name, d.typ))
+		}
+		v.vars[d.name] = true
+	}
+	v.selections(s.byName["Query"], op.sel, 1, false, map[string]bool{})
+	return op, v.errs
+}
+
+func (v *gqlValidator) fail(s *gqlSelection, format string, args ...interface{}) {
+	v.errs = append(v.errs, invalidQuery(s.line, s.col, format, args...))
+}
+
+// selections checks a selection set on type t; inList tells whether it
+// resolves once per item of a list, and spreading marks the fragments being
+// expanded, to catch cycles
+func (v *gqlValidator) selections(t *gqlObjectType, sels []*gqlSelection, depth int, inList bool, spreading map[string]bool) {
+	if depth > gqlMaxDepth {
+		v.fail(sels[0], "query is nested deeper than %d levels", gqlMaxDepth)
+		return
+	}
+	for _, s := range sels {
+		for _, d := range s.directives {
+			if d.name != "skip" && d.name != "include" {
+				v.fail(s, "unknown directive @%s", d.name)
+			}
+			v.values(s, d.args)
+		}
+
+		switch s.kind {
+		case selSpread:
+			f := v.doc.fragments[s.name]
+			switch {
+			case f == nil:
+				v.fail(s, "unknown fragment %q", s.name)
+			case spreading[s.name]:
+				v.fail(s, "fragment %q spreads itself", s.name)
+			case f.typeCond != t.Name:
+				v.fail(s, "fragment %q on %s can't be spread on type %s", s.name, f.typeCond, t.Name)
+			default:
+				spreading[s.name] = true
+				v.selections(t, f.sel, depth, inList, spreading)
+				delete(spreading, s.name)
+			}
+
+		case selInline:
+			if s.typeCond != "" && s.typeCond != t.Name {
+				v.fail(s, "fragment on %s can't be spread on type %s", s.typeCond, t.Name)
+				continue
+			}
+			v.selections(t, s.sel, depth, inList, spreading)
+
+		case selField:
+			if s.name == "__typename" {
+				if s.sel != nil {
+					v.fail(s, "field __typename can't have a selection")
+				}
+				continue
+			}
+			f := t.field(s.name)
+			if f == nil {
+				v.fail(s, "cannot query field %q on type %s", s.name, t.Name)
+				continue
+			}
+			v.arguments(s, f)
+			if f.Search {
+				v.search(s, inList)
+			}
+			if obj := v.schema.byName[f.typ.named()]; obj != nil {
+				if s.sel == nil {
+					v.fail(s, "field %q of type %s must have a selection of subfields", s.name, f.Type)
+					continue
+				}
+				v.selections(obj, s.sel, depth+1, inList || f.typ.Elem != nil, spreading)
+			} else if s.sel != nil {
+				v.fail(s, "field %q of type %s can't have a selection", s.name, f.Type)
+			}
+		}
+	}
+}
+
+// search counts a field that runs a search. The searches of a query are
+// capped like the targets of a batch; inside a list their number would
+// depend on the data, so it's refused there.
+func (v *gqlValidator) search(s *gqlSelection, inList bool) {
+	if inList {
+		v.fail(s, "field %q runs a search and can't be selected inside a list", s.name)
+		return
+	}
+	if v.searches++; v.searches == *batchMaxTargets+1 {
+		v.fail(s, "query runs more than %d searches", *batchMaxTargets)
+	}
+}
+
+// arguments checks that the arguments of a field exist and required ones are given
+func (v *gqlValidator) arguments(s *gqlSelection, f *gqlFieldDef) {
+	given := make(map[string]bool)
+	for _, a := range s.args {
+		known := false
+		for _, def := range f.Args {
+			known = known || def.Name == a.name
+		}
+		if !known {
+			v.fail(s, "unknown argument %q on field %q", a.name, f.Name)
+		}
+		given[a.name] = true
+	}
+	for _, def := range f.Args {
+		if def.typ.NonNull && def.Default == nil && !given[def.Name] {
+			v.fail(s, "field %q requires argument %q of type %s", f.Name, def.Name, def.Type)
+		}
+	}
+	v.values(s, s.args)
+}
+
+// values checks that every variable used in args is declared
+func (v *gqlValidator) values(s *gqlSelection, args []gqlArgument) {
+	var walk func(val interface{})
+	walk = func(val interface{}) {
+		switch x := val.(type) {
+		case gqlVar:
+			if !v.vars[string(x)] {
+				v.fail(s, "variable $%s is not defined", x)
+			}
+		case []interface{}:
+			for _, e := range x {
+				walk(e)
+			}
+		case map[string]interface{}:
+			for _, e := range x {
+				walk(e)
+			}
+		}
+	}
+	for _, a := range args {
+		walk(a.value)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Execution
+// -----------------------------------------------------------------------------
+
+// gqlExec runs one validated operation. Fields resolve one after another,
+// so resolvers may share state through it without locking.
+type gqlExec struct {
+	ctx    context.Context
+	schema *gqlSchemaDef
+	doc    *gqlDocument
+	vars   map[string]interface{}
+	errs   []gqlError
+	fields int
+	graph  *recipeFinder.ElementGraph // per-element lookups of the active snapshot
+}
+
+// gqlObject is a resolved object with its fields in query order
+type gqlObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *gqlObject) set(key string, v interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+func (o *gqlObject) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		val, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(val)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
+
+// coerceVariables applies the declared types and defaults to the request variables
+func (ex *gqlExec) coerceVariables(op *gqlOperation, given map[string]interface{}) []gqlError {
+	var errs []gqlError
+	for _, d := range op.vars {
+		raw, ok := given[d.name]
+		if !ok {
+			raw = d.def
+		}
+		v, err := coerceInput(raw, d.typ)
+		if err != nil {
+			errs = append(errs, invalidQuery(d.line, d.col, "variable $%s: %v", d.name, err))
+			continue
+		}
+		ex.vars[d.name] = v
+	}
+	return errs
+}
+
+// coerceInput converts a literal or JSON variable value to the input type t
+func coerceInput(v interface{}, t *gqlType) (interface{}, error) {
+	if v == nil {
+		if t.NonNull {
+			return nil, fmt.Errorf("expected a non-null %s", t)
+		}
+		return nil, nil
+	}
+	if t.Elem != nil {
+		list, ok := v.([]interface{})
+		if !ok {
+			list = []interface{}{v} // a single value counts as a list of one
+		}
+		out := make([]interface{}, len(list))
+		for i, e := range list {
+			c, err := coerceInput(e, t.Elem)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	}
+	switch t.Name {
+	case "String":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case "Int":
+		switch n := v.(type) {
+		case int:
+			if n >= math.MinInt32 && n <= math.MaxInt32 {
+				return n, nil
+			}
+		case float64: // JSON variables
+			if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int(n), nil
+			}
+		}
+	case "Float":
+		switch n := v.(type) {
+		case int:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+	case "Boolean":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %v", t, v)
+}
+
+// resolveVars replaces variable references in a literal by their values
+func (ex *gqlExec) resolveVars(v interface{}) interface{} {
+	switch x := v.(type) {
+	case gqlVar:
+		return ex.vars[string(x)]
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, e := range x {
+			out[i] = ex.resolveVars(e)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, e := range x {
+			out[k] = ex.resolveVars(e)
+		}
+		return out
+	}
+	return v
+}
+
+// included applies @skip(if:) and @include(if:)
+func (ex *gqlExec) included(s *gqlSelection) bool {
+	for _, d := range s.directives {
+		for _, a := range d.args {
+			if a.name != "if" {
+				continue
+			}
+			cond, _ := ex.resolveVars(a.value).(bool)
+			if d.name == "skip" && cond || d.name == "include" && !cond {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// collect flattens fragments into the fields of a selection set, grouped by
+// response key in query order
+func (ex *gqlExec) collect(sels []*gqlSelection, keys *[]string, groups map[string][]*gqlSelection) {
+	for _, s := range sels {
+		if !ex.included(s) {
+			continue
+		}
+		switch s.kind {
+		case selField:
+			k := s.key()
+			if _, ok := groups[k]; !ok {
+				*keys = append(*keys, k)
+			}
+			groups[k] = append(groups[k], s)
+		case selSpread:
+			ex.collect(ex.doc.fragments[s.name].sel, keys, groups)
+		case selInline:
+			ex.collect(s.sel, keys, groups)
+		}
+	}
+}
+
+// object resolves the selected fields of a value of object type t
+func (ex *gqlExec) object(t *gqlObjectType, parent interface{}, sels []*gqlSelection, path []interface{}) *gqlObject {
+	var keys []string
+	groups := make(map[string][]*gqlSelection)
+	ex.collect(sels, &keys, groups)
+
+	out := &gqlObject{values: make(map[string]interface{})}
+	for _, k := range keys {
+		nodes := groups[k]
+		s := nodes[0]
+		fieldPath := append(append([]interface{}{}, path...), k)
+
+		if s.name == "__typename" {
+			out.set(k, t.Name)
+			continue
+		}
+		if ex.fields++; ex.fields > gqlMaxFields {
+			if ex.fields == gqlMaxFields+1 {
+				ex.fieldError(s, fieldPath, &APIError{Code: codeInvalidQuery,
+					Message: fmt.Sprintf("query resolves more than %d fields", gqlMaxFields)})
+			}
+			out.set(k, nil)
+			continue
+		}
+
+		f := t.field(s.name)
+		args, err := ex.arguments(s, f)
+		var v interface{}
+		if err == nil {
+			v, err = f.Resolve(ex, parent, args)
+		}
+		if err != nil {
+			ex.fieldError(s, fieldPath, err)
+			out.set(k, nil)
+			continue
+		}
+
+		// Fields selected more than once under one key merge their selections
+		var sub []*gqlSelection
+		for _, n := range nodes {
+			sub = append(sub, n.sel...)
+		}
+		out.set(k, ex.complete(f.typ, v, sub, fieldPath))
+	}
+	return out
+}
+
+// arguments coerces the arguments of a field, filling in defaults
+func (ex *gqlExec) arguments(s *gqlSelection, f *gqlFieldDef) (map[string]interface{}, error) {
+	args := make(map[string]interface{}, len(f.Args))
+	for _, def := range f.Args {
+		raw := def.Default
+		for _, a := range s.args {
+			if a.name == def.Name {
+				raw = ex.resolveVars(a.value)
+			}
+		}
+		v, err := coerceInput(raw, def.typ)
+		if err != nil {
+			return nil, &APIError{Code: codeInvalidParameter, Message: fmt.Sprintf("argument %q: %v", def.Name, err),
+				Details: map[string]string{"parameter": def.Name}}
+		}
+		args[def.Name] = v
+	}
+	return args, nil
+}
+
+// complete turns a resolved value into its response form according to t
+func (ex *gqlExec) complete(t *gqlType, v interface{}, sel []*gqlSelection, path []interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if t.Elem != nil {
+		list := v.([]interface{})
+		out := make([]interface{}, len(list))
+		for i, e := range list {
+			out[i] = ex.complete(t.Elem, e, sel, append(append([]interface{}{}, path...), i))
+		}
+		return out
+	}
+	if obj := ex.schema.byName[t.Name]; obj != nil {
+		return ex.object(obj, v, sel, path)
+	}
+	return v // scalar
+}
+
+// fieldError records a resolver error; the field itself becomes null
+func (ex *gqlExec) fieldError(s *gqlSelection, path []interface{}, err error) {
+	e := gqlError{Message: err.Error(), Locations: []gqlLocation{{s.line, s.col}}, Path: path}
+	if apiErr, ok := err.(*APIError); ok {
+		e.Extensions = map[string]interface{}{"code": apiErr.Code}
+		if apiErr.Details != nil {
+			e.Extensions["details"] = apiErr.Details
+		}
+	}
+	ex.errs = append(ex.errs, e)
+}
+
+// -----------------------------------------------------------------------------
+// HTTP
+// -----------------------------------------------------------------------------
+
+// graphqlRequest is the body of POST /api/v1/graphql; GET takes the same
+// fields as query parameters (variables JSON encoded)
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphqlResponse is the body of every /api/v1/graphql answer. Data is left
+// out when the query never ran (it didn't parse or validate).
+type graphqlResponse struct {
+	Data   *gqlObject `json:"data,omitempty"`
+	Errors []gqlError `json:"errors,omitempty"`
+}
+
+// writeGraphQL sends a GraphQL response with the given HTTP status
+func writeGraphQL(w http.ResponseWriter, status int, resp graphqlResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleGraphQL serves /api/v1/graphql. Requests that don't parse or
+// validate get 400 without data; otherwise the answer is 200 with data and
+// the errors of single fields (an unknown element, a failed search) next to it.
+// Errors of domain code carry its error code in extensions.code.
+func handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphqlRequest
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeGraphQL(w, http.StatusBadRequest, graphqlResponse{Errors: []gqlError{
+					invalidQuery(1, 1, "variables must be a JSON object: %v", err)}})
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, gqlMaxBody)).Decode(&req); err != nil {
+			writeGraphQL(w, http.StatusBadRequest, graphqlResponse{Errors: []gqlError{
+				invalidQuery(1, 1, "invalid GraphQL request: %v", err)}})
+			return
+		}
+	default:
+		writeMethodNotAllowed(w, "GET, POST")
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		writeGraphQL(w, http.StatusBadRequest, graphqlResponse{Errors: []gqlError{invalidQuery(1, 1, "missing query")}})
+		return
+	}
+
+	doc, err := parseGraphQL(req.Query)
+	if err != nil {
+		e := invalidQuery(1, 1, "%s", err.Error())
+		if se, ok := err.(*gqlSyntaxError); ok {
+			e = invalidQuery(se.line, se.col, "syntax error: %s", se.msg)
+		}
+		writeGraphQL(w, http.StatusBadRequest, graphqlResponse{Errors: []gqlError{e}})
+		return
+	}
+	op, errs := graphQLSchema.validate(doc, req.OperationName)
+	if len(errs) > 0 {
+		writeGraphQL(w, http.StatusBadRequest, graphqlResponse{Errors: errs})
+		return
+	}
+
+	// The whole query sees one snapshot; its searches share one time budget
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	extendDeadlines(w, *searchTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), *searchTimeout)
+	defer cancel()
+
+	ex := &gqlExec{
+		ctx:    ctx,
+		schema: graphQLSchema,
+		doc:    doc,
+		vars:   make(map[string]interface{}),
+		graph:  recipeFinder.NewElementGraph(recipeFinder.GlobalIndexedGraph),
+	}
+	if errs := ex.coerceVariables(op, req.Variables); len(errs) > 0 {
+		writeGraphQL(w, http.StatusBadRequest, graphqlResponse{Errors: errs})
+		return
+	}
+	data := ex.object(graphQLSchema.byName["Query"], nil, op.sel, nil)
+
+	if r.Context().Err() != nil {
+		return // client went away
+	}
+	writeGraphQL(w, http.StatusOK, graphqlResponse{Data: data, Errors: ex.errs})
+	recipeFinder.Logger(r.Context()).Info("graphql", "operation", op.name, "fields", ex.fields, "errors", len(ex.errs))
+}
+
+// handleGraphQLSchema serves /api/v1/graphql/schema — the schema as SDL
+func handleGraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, graphQLSchema.SDL())
+}
@@ -0,0 +1,263 @@
+// backend/graphql_schema.go
+package main
+
+// The GraphQL schema over the element graph, served at /api/v1/graphql:
+//
+//	{
+//	  element(name: "Brick") {
+//	    tier depth
+//	    recipes { a { name } b { name } }
+//	    usedIn { partner { name } product { name tier } }
+//	    findRecipe(algorithm: "dfs", maxPaths: 3) { trees nodesVisited }
+//	  }
+//	  path(from: "Water", to: "Brick") { ingredient { name } partner { name } product { name } }
+//	}
+//
+// Names resolve like everywhere else (case-insensitive, aliases); an unknown
+// one makes its field null with an unknown_element error.
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// gqlElement is the resolved value of an Element: its canonical name
+type gqlElement string
+
+// resolveElement maps a requested name to an Element
+func resolveElement(name string) (gqlElement, error) {
+	canonical, ok := recipeFinder.GlobalNameResolver.Resolve(name)
+	if !ok {
+		apiErr := unknownElementError(name)
+		return "", &apiErr
+	}
+	return gqlElement(canonical), nil
+}
+
+// elementField is a resolver for a field of Element that only needs the name
+func elementField(f func(ex *gqlExec, name string) interface{}) gqlResolver {
+	return func(ex *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+		return f(ex, string(parent.(gqlElement))), nil
+	}
+}
+
+// asList converts a typed slice for list fields, mapping each item
+func asList[T any](items []T, f func(T) interface{}) []interface{} {
+	out := make([]interface{}, len(items))
+	for i, it := range items {
+		out[i] = f(it)
+	}
+	return out
+}
+
+var graphQLSchema = newGQLSchema(
+	[]gqlScalar{
+		{Name: "String", builtin: true},
+		{Name: "Int", builtin: true},
+		{Name: "Float", builtin: true},
+		{Name: "Boolean", builtin: true},
+		{Name: "RecipeTree", Doc: "A recipe tree as returned by /api/v1/find: {name, children}"},
+	},
+
+	// ---------- Query ----------
+	&gqlObjectType{Name: "Query", Fields: []*gqlFieldDef{
+		{
+			Name: "element", Type: "Element", Doc: "One element by name (case-insensitive, aliases allowed)",
+			Args: []gqlArgDef{{Name: "name", Type: "String!"}},
+			Resolve: func(ex *gqlExec, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				el, err := resolveElement(args["name"].(string))
+				if err != nil {
+					return nil, err
+				}
+				return el, nil
+			},
+		},
+		{
+			Name: "elements", Type: "[Element!]!", Doc: "Every element in catalog order, optionally of one tier only",
+			Args: []gqlArgDef{{Name: "tier", Type: "String"}},
+			Resolve: func(ex *gqlExec, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				tier, _ := args["tier"].(string)
+				elements := []interface{}{}
+				for _, t := range recipeFinder.GlobalCatalog.Tiers {
+					if tier != "" && t.Name != tier {
+						continue
+					}
+					for _, el := range t.Elements {
+						elements = append(elements, gqlElement(el.Name))
+					}
+				}
+				return elements, nil
+			},
+		},
+		{
+			Name: "path", Type: "[PathStep!]",
+			Doc:  "The shortest chain of combinations from one element to another; null if there is none",
+			Args: []gqlArgDef{{Name: "from", Type: "String!"}, {Name: "to", Type: "String!"}},
+			Resolve: func(ex *gqlExec, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				from, err := resolveElement(args["from"].(string))
+				if err != nil {
+					return nil, err
+				}
+				to, err := resolveElement(args["to"].(string))
+				if err != nil {
+					return nil, err
+				}
+				steps, ok := ex.graph.Path(string(from), string(to))
+				if !ok {
+					return nil, nil
+				}
+				return asList(steps, func(s recipeFinder.PathStep) interface{} { return s }), nil
+			},
+		},
+		{
+			Name: "snapshot", Type: "String!", Doc: "Version of the catalog every field was read from",
+			Resolve: func(*gqlExec, interface{}, map[string]interface{}) (interface{}, error) {
+				return catalogVersion, nil
+			},
+		},
+	}},
+
+	// ---------- Element ----------
+	&gqlObjectType{Name: "Element", Fields: []*gqlFieldDef{
+		{Name: "name", Type: "String!", Resolve: elementField(func(_ *gqlExec, name string) interface{} {
+			return name
+		})},
+		{Name: "tier", Type: "String!", Doc: `Tier name as on the wiki ("Starting", "1", ...)`,
+			Resolve: elementField(func(_ *gqlExec, name string) interface{} {
+				return recipeFinder.GlobalElementIndex[name].Tier
+			})},
+		{Name: "tierLevel", Type: "Int!", Doc: "Numeric tier level used by the searches",
+			Resolve: elementField(func(_ *gqlExec, name string) interface{} {
+				return recipeFinder.GlobalElementIndex[name].TierLevel
+			})},
+		{Name: "svgPath", Type: "String!", Resolve: elementField(func(_ *gqlExec, name string) interface{} {
+			return recipeFinder.GlobalElementIndex[name].SVGPath
+		})},
+		{Name: "isBase", Type: "Boolean!", Resolve: elementField(func(ex *gqlExec, name string) interface{} {
+			return ex.graph.IsBase(name)
+		})},
+		{Name: "depth", Type: "Int!", Doc: "Minimal number of combination levels from the base set; -1 if unreachable",
+			Resolve: elementField(func(ex *gqlExec, name string) interface{} {
+				return ex.graph.Depth(name)
+			})},
+		{Name: "recipeTreeCount", Type: "String!", Doc: "Number of distinct full recipe trees, in decimal (it outgrows Int)",
+			Resolve: elementField(func(ex *gqlExec, name string) interface{} {
+				return ex.graph.RecipeTreeCount(name).String()
+			})},
+		{Name: "recipes", Type: "[Recipe!]!", Doc: "Valid recipes that make this element",
+			Resolve: elementField(func(ex *gqlExec, name string) interface{} {
+				return asList(ex.graph.Recipes(name), func(c recipeFinder.IngredientCombo) interface{} { return c })
+			})},
+		{Name: "usedIn", Type: "[Usage!]!", Doc: "Recipes this element is an ingredient of",
+			Resolve: elementField(func(ex *gqlExec, name string) interface{} {
+				return asList(ex.graph.UsedIn(name), func(u recipeFinder.ElementUsage) interface{} { return u })
+			})},
+		{
+			Name: "findRecipe", Type: "SearchResult",
+			Doc: "Runs a search like /api/v1/find. Without maxPaths it finds a single recipe; " +
+				"with it, up to maxPaths distinct ones. Null with an error if the search fails. " +
+				"A query may run -batch-max-targets searches, and none inside a list.",
+			Args:   []gqlArgDef{{Name: "algorithm", Type: "String", Default: "bfs"}, {Name: "maxPaths", Type: "Int"}},
+			Search: true,
+			Resolve: func(ex *gqlExec, parent interface{}, args map[string]interface{}) (interface{}, error) {
+				return resolveFindRecipe(ex, string(parent.(gqlElement)), args)
+			},
+		},
+	}},
+
+	// ---------- Relationships ----------
+	&gqlObjectType{Name: "Recipe", Doc: "Two ingredients that combine into an element", Fields: []*gqlFieldDef{
+		{Name: "a", Type: "Element!", Resolve: func(_ *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return gqlElement(parent.(recipeFinder.IngredientCombo).A), nil
+		}},
+		{Name: "b", Type: "Element!", Resolve: func(_ *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return gqlElement(parent.(recipeFinder.IngredientCombo).B), nil
+		}},
+	}},
+	&gqlObjectType{Name: "Usage", Doc: "A recipe an element takes part in: element + partner → product", Fields: []*gqlFieldDef{
+		{Name: "partner", Type: "Element!", Resolve: func(_ *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return gqlElement(parent.(recipeFinder.ElementUsage).Partner), nil
+		}},
+		{Name: "product", Type: "Element!", Resolve: func(_ *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return gqlElement(parent.(recipeFinder.ElementUsage).Product), nil
+		}},
+	}},
+	&gqlObjectType{Name: "PathStep", Doc: "One combination on a path: ingredient + partner → product", Fields: []*gqlFieldDef{
+		{Name: "ingredient", Type: "Element!", Resolve: func(_ *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return gqlElement(parent.(recipeFinder.PathStep).Ingredient), nil
+		}},
+		{Name: "partner", Type: "Element!", Resolve: func(_ *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return gqlElement(parent.(recipeFinder.PathStep).Partner), nil
+		}},
+		{Name: "product", Type: "Element!", Resolve: func(_ *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return gqlElement(parent.(recipeFinder.PathStep).Product), nil
+		}},
+	}},
+
+	// ---------- Searches ----------
+	&gqlObjectType{Name: "SearchResult", Doc: "The outcome of findRecipe, as in the /api/v1/find body", Fields: []*gqlFieldDef{
+		{Name: "algorithm", Type: "String!", Resolve: searchField(func(res *FindResult) interface{} { return res.Algorithm })},
+		{Name: "trees", Type: "[RecipeTree!]!", Resolve: searchField(func(res *FindResult) interface{} {
+			return asList(res.Trees, func(t *recipeFinder.RecipeNode) interface{} { return t })
+		})},
+		{Name: "nodesVisited", Type: "Int!", Resolve: searchField(func(res *FindResult) interface{} { return res.NodesVisited })},
+		{Name: "durationMs", Type: "Float!", Resolve: searchField(func(res *FindResult) interface{} { return res.DurationMs })},
+		{Name: "truncated", Type: "Boolean!", Doc: "The time budget ran out; trees holds partial results",
+			Resolve: searchField(func(res *FindResult) interface{} { return res.Truncated })},
+		{Name: "precomputed", Type: "Boolean!", Doc: "The answer came from the warm-up recipe table",
+			Resolve: searchField(func(res *FindResult) interface{} { return res.Precomputed })},
+		{Name: "cached", Type: "Boolean!", Doc: "The answer came from the /find response cache",
+			Resolve: func(_ *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+				return parent.(BatchFindItem).Cached, nil
+			}},
+	}},
+)
+
+// searchField is a resolver for a field of SearchResult
+func searchField(f func(res *FindResult) interface{}) gqlResolver {
+	return func(_ *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+		return f(parent.(BatchFindItem).Result), nil
+	}
+}
+
+// resolveFindRecipe validates the arguments of Element.findRecipe like
+// /find validates its query and runs the search through the response cache.
+// All searches of one query share its time budget (-search-timeout).
+func resolveFindRecipe(ex *gqlExec, target string, args map[string]interface{}) (interface{}, error) {
+	p := findParams{Target: target, MaxPaths: 5, Algorithm: "bfs", Timeout: *searchTimeout, Steps: "none"}
+	p.useActiveSnapshot() // handleGraphQL holds catalogMu for the whole query
+	if n, ok := args["maxPaths"].(int); ok {
+		if n <= 0 {
+			return nil, &APIError{Code: codeInvalidParameter, Message: "maxPaths must be a positive integer",
+				Details: map[string]string{"parameter": "maxPaths"}}
+		}
+		p.Multi, p.MaxPaths = true, n
+	}
+
+	switch algo, _ := args["algorithm"].(string); algo {
+	case "", "bfs", "dfs":
+		if algo != "" {
+			p.Algorithm = algo
+		}
+	case "bidirectional":
+		if p.Multi {
+			return nil, &APIError{Code: codeInvalidParameter, Message: "bidirectional search only supports a single recipe, leave out maxPaths",
+				Details: map[string]string{"parameter": "maxPaths"}}
+		}
+		p.Algorithm = algo
+	default:
+		return nil, &APIError{Code: codeInvalidParameter, Message: "algorithm must be bfs, dfs or bidirectional",
+			Details: map[string]string{"parameter": "algorithm"}}
+	}
+
+	item := cachedFind(ex.ctx, p)
+	if item.Error != nil {
+		return nil, item.Error
+	}
+	if item.Status != http.StatusOK || item.Result == nil {
+		return nil, fmt.Errorf("search for %q failed", target)
+	}
+	return item, nil
+}
@@ -0,0 +1,735 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// -----------------------------------------------------------------------------
+// Test schema
+// -----------------------------------------------------------------------------
+
+// testItem is the value of an Item in the test schema; its child has the next id
+type testItem int
+
+var testSchema = newGQLSchema(
+	[]gqlScalar{
+		{Name: "String", builtin: true},
+		{Name: "Int", builtin: true},
+		{Name: "Float", builtin: true},
+		{Name: "Boolean", builtin: true},
+	},
+	&gqlObjectType{Name: "Query", Fields: []*gqlFieldDef{
+		{Name: "hello", Type: "String!", Args: []gqlArgDef{{Name: "name", Type: "String", Default: "world"}},
+			Resolve: func(_ *gqlExec, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				name, _ := args["name"].(string)
+				return "hello " + name, nil
+			}},
+		{Name: "num", Type: "Int", Args: []gqlArgDef{{Name: "n", Type: "Int!"}},
+			Resolve: func(_ *gqlExec, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				return args["n"], nil
+			}},
+		{Name: "sum", Type: "Int!", Args: []gqlArgDef{{Name: "ns", Type: "[Int!]!"}},
+			Resolve: func(_ *gqlExec, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				total := 0
+				for _, n := range args["ns"].([]interface{}) {
+					total += n.(int)
+				}
+				return total, nil
+			}},
+		{Name: "item", Type: "Item", Resolve: func(*gqlExec, interface{}, map[string]interface{}) (interface{}, error) {
+			return testItem(1), nil
+		}},
+		{Name: "items", Type: "[Item!]!", Args: []gqlArgDef{{Name: "count", Type: "Int!"}},
+			Resolve: func(_ *gqlExec, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				items := make([]interface{}, args["count"].(int))
+				for i := range items {
+					items[i] = testItem(i)
+				}
+				return items, nil
+			}},
+		{Name: "fail", Type: "String", Resolve: func(*gqlExec, interface{}, map[string]interface{}) (interface{}, error) {
+			return nil, &APIError{Code: codeUnknownElement, Message: "no such thing", Details: map[string]string{"name": "x"}}
+		}},
+		{Name: "broken", Type: "String", Resolve: func(*gqlExec, interface{}, map[string]interface{}) (interface{}, error) {
+			return nil, errors.New("plain failure")
+		}},
+	}},
+	&gqlObjectType{Name: "Item", Fields: []*gqlFieldDef{
+		{Name: "id", Type: "Int!", Resolve: func(_ *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return int(parent.(testItem)), nil
+		}},
+		{Name: "child", Type: "Item", Resolve: func(_ *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return parent.(testItem) + 1, nil
+		}},
+	}},
+)
+
+// execute runs a query on the test schema the way handleGraphQL does.
+// Parse and validation errors fail the test; variable errors are returned
+// without data, like the handler answers them.
+func execute(t *testing.T, query, variables string) graphqlResponse {
+	t.Helper()
+	doc, err := parseGraphQL(query)
+	if err != nil {
+		t.Fatalf("parse %q: %v", query, err)
+	}
+	op, errs := testSchema.validate(doc, "")
+	if len(errs) > 0 {
+		t.Fatalf("validate %q: %v", query, errs)
+	}
+	var vars map[string]interface{}
+	if variables != "" {
+		if err := json.Unmarshal([]byte(variables), &vars); err != nil {
+			t.Fatalf("variables %s: %v", variables, err)
+		}
+	}
+	ex := &gqlExec{ctx: context.Background(), schema: testSchema, doc: doc, vars: make(map[string]interface{})}
+	if errs := ex.coerceVariables(op, vars); len(errs) > 0 {
+		return graphqlResponse{Errors: errs}
+	}
+	return graphqlResponse{Data: ex.object(testSchema.byName["Query"], nil, op.sel, nil), Errors: ex.errs}
+}
+
+// dataJSON is the data of a response as JSON, in response key order
+func dataJSON(t *testing.T, resp graphqlResponse) string {
+	t.Helper()
+	b, err := json.Marshal(resp.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// -----------------------------------------------------------------------------
+// Parser
+// -----------------------------------------------------------------------------
+
+func TestParseGraphQLDocument(t *testing.T) {
+	doc, err := parseGraphQL(`
+		# a comment, and commas are ignored
+		query Q($n: Int = 2, $s: Boolean!) {
+			a: hello(name: "x") @skip(if: $s)
+			...F
+			... on Query { num(n: $n) }
+			... @include(if: true) { hello }
+		}
+		fragment F on Query { item { id } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.operations) != 1 {
+		t.Fatalf("%d operations, want 1", len(doc.operations))
+	}
+	op := doc.operations[0]
+	if op.kind != "query" || op.name != "Q" {
+		t.Errorf("operation %s %s, want query Q", op.kind, op.name)
+	}
+	if len(op.vars) != 2 || op.vars[0].name != "n" || op.vars[0].typ.String() != "Int" || op.vars[0].def != 2 ||
+		op.vars[1].name != "s" || op.vars[1].typ.String() != "Boolean!" || op.vars[1].def != nil {
+		t.Errorf("variables: %+v", op.vars)
+	}
+
+	if len(op.sel) != 4 {
+		t.Fatalf("%d selections, want 4", len(op.sel))
+	}
+	a := op.sel[0]
+	if a.kind != selField || a.alias != "a" || a.name != "hello" || a.key() != "a" {
+		t.Errorf("aliased field: %+v", a)
+	}
+	if len(a.args) != 1 || a.args[0].name != "name" || a.args[0].value != "x" {
+		t.Errorf("arguments: %+v", a.args)
+	}
+	if len(a.directives) != 1 || a.directives[0].name != "skip" || a.directives[0].args[0].value != gqlVar("s") {
+		t.Errorf("directives: %+v", a.directives)
+	}
+	if s := op.sel[1]; s.kind != selSpread || s.name != "F" {
+		t.Errorf("fragment spread: %+v", s)
+	}
+	if s := op.sel[2]; s.kind != selInline || s.typeCond != "Query" || len(s.sel) != 1 {
+		t.Errorf("inline fragment: %+v", s)
+	}
+	if s := op.sel[3]; s.kind != selInline || s.typeCond != "" || len(s.directives) != 1 {
+		t.Errorf("inline fragment without type: %+v", s)
+	}
+	if a.line != 4 {
+		t.Errorf("field at line %d, want 4", a.line)
+	}
+
+	f := doc.fragments["F"]
+	if f == nil || f.typeCond != "Query" || len(f.sel) != 1 || f.sel[0].name != "item" {
+		t.Errorf("fragment F: %+v", f)
+	}
+}
+
+func TestParseGraphQLValues(t *testing.T) {
+	doc, err := parseGraphQL(`{ f(a: 1, b: -2.5e3, c: "s\n\u00e9", d: true, e: null, f: RED,
+		g: [1, [2]], h: {x: 1, y: $v}, i: """block "quoted" text""") }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]interface{}{}
+	for _, a := range doc.operations[0].sel[0].args {
+		got[a.name] = a.value
+	}
+	want := map[string]interface{}{
+		"a": 1,
+		"b": -2500.0,
+		"c": "s\né",
+		"d": true,
+		"e": nil,
+		"f": gqlEnum("RED"),
+		"g": []interface{}{1, []interface{}{2}},
+		"h": map[string]interface{}{"x": 1, "y": gqlVar("v")},
+		"i": `block "quoted" text`,
+	}
+	for k, w := range want {
+		if fmt.Sprintf("%#v", got[k]) != fmt.Sprintf("%#v", w) {
+			t.Errorf("%s = %#v, want %#v", k, got[k], w)
+		}
+	}
+}
+
+func TestParseGraphQLMalformed(t *testing.T) {
+	cases := []struct {
+		query, msg string
+		line, col  int // position of the error, 0 = don't check
+	}{
+		{"", "no operation", 1, 1},
+		{"# only a comment", "no operation", 1, 1},
+		{"{", `expected "}"`, 0, 0},
+		{"{ hello", `expected "}"`, 0, 0},
+		{"{ }", "empty selection set", 1, 3},
+		{"{ hello } }", "expected an operation or fragment", 1, 11},
+		{"hello", "expected an operation or fragment", 1, 1},
+		{"{ hello(name: ) }", "expected a value", 0, 0},
+		{"{ hello(name \"x\") }", `expected ":"`, 0, 0},
+		{"{ hello(name: \"x) }", "unterminated string", 1, 15},
+		{"{ hello(name: \"\"\"x) }", "unterminated block string", 0, 0},
+		{"{ hello(name: \"\\q\") }", "invalid string", 0, 0},
+		{"{ num(n: 99999999999999999999) }", "invalid integer", 0, 0},
+		{"{ sum(ns: [1, 2) }", `expected a value, found ")"`, 0, 0},
+		{"{ hello ^ }", "unexpected character", 1, 9},
+		{"{ a: }", "expected a name", 0, 0},
+		{"{ ... on { id } }", "expected a name", 0, 0},
+		{"query ($n Int) { num(n: $n) }", `expected ":"`, 0, 0},
+		{"query ($n: ) { hello }", "expected a name", 0, 0},
+		{"query ($n: [Int) { hello }", `expected "]"`, 0, 0},
+		{"query ($n: Int = $m) { hello }", "variables are not allowed", 0, 0},
+		{"fragment F Query { hello } { hello }", `expected "on"`, 0, 0},
+		{"fragment F on Query { hello } fragment F on Query { hello } { ...F }", "defined twice", 0, 0},
+		{"{ hello @ }", "expected a name", 0, 0},
+	}
+	for _, c := range cases {
+		_, err := parseGraphQL(c.query)
+		if err == nil {
+			t.Errorf("%q: parsed, want an error containing %q", c.query, c.msg)
+			continue
+		}
+		var se *gqlSyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("%q: %T error, want *gqlSyntaxError", c.query, err)
+			continue
+		}
+		if !strings.Contains(se.msg, c.msg) {
+			t.Errorf("%q: error %q, want it to contain %q", c.query, se.msg, c.msg)
+		}
+		if c.line != 0 && (se.line != c.line || se.col != c.col) {
+			t.Errorf("%q: error at %d:%d, want %d:%d", c.query, se.line, se.col, c.line, c.col)
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Validation
+// -----------------------------------------------------------------------------
+
+// nested is an Item selection n child levels deep
+func nested(n int) string {
+	return "{ item { " + strings.Repeat("child { ", n) + "id" + strings.Repeat(" }", n) + " } }"
+}
+
+func TestValidateGraphQL(t *testing.T) {
+	cases := []struct{ query, opName, msg string }{
+		{"{ nope }", "", `cannot query field "nope" on type Query`},
+		{"{ item { nope } }", "", `cannot query field "nope" on type Item`},
+		{`{ hello(who: "x") }`, "", `unknown argument "who" on field "hello"`},
+		{"{ num }", "", `field "num" requires argument "n" of type Int!`},
+		{"{ hello { id } }", "", `field "hello" of type String! can't have a selection`},
+		{"{ item }", "", `field "item" of type Item must have a selection of subfields`},
+		{"{ __typename { id } }", "", "__typename can't have a selection"},
+		{"{ num(n: $x) }", "", "variable $x is not defined"},
+		{"{ sum(ns: [1, $x]) }", "", "variable $x is not defined"},
+		{"{ hello @skip(if: $nope) }", "", "variable $nope is not defined"},
+		{"query ($x: Thing) { hello }", "", "variable $x has unknown input type Thing"},
+		{"query ($x: Item) { hello }", "", "variable $x has unknown input type Item"},
+		{"{ hello @deprecated }", "", "unknown directive @deprecated"},
+		{"{ ...Missing }", "", `unknown fragment "Missing"`},
+		{"{ ...F } fragment F on Item { id }", "", `fragment "F" on Item can't be spread on type Query`},
+		{"{ ... on Item { id } }", "", "fragment on Item can't be spread on type Query"},
+		{"{ ...A } fragment A on Query { ...A }", "", `fragment "A" spreads itself`},
+		{"{ item { ...A } } fragment A on Item { child { ...B } } fragment B on Item { ...A }", "", `fragment "A" spreads itself`},
+		{"mutation { hello }", "", "only queries are supported, not mutation"},
+		{"subscription { hello }", "", "only queries are supported, not subscription"},
+		{"query A { hello } query B { hello }", "", "operationName is required"},
+		{"query A { hello } query B { hello }", "C", `unknown operation "C"`},
+		{nested(gqlMaxDepth - 1), "", fmt.Sprintf("nested deeper than %d levels", gqlMaxDepth)},
+	}
+	for _, c := range cases {
+		doc, err := parseGraphQL(c.query)
+		if err != nil {
+			t.Errorf("%q: %v", c.query, err)
+			continue
+		}
+		_, errs := testSchema.validate(doc, c.opName)
+		found := false
+		for _, e := range errs {
+			found = found || strings.Contains(e.Message, c.msg)
+			if e.Extensions["code"] != codeInvalidQuery || len(e.Locations) != 1 {
+				t.Errorf("%q: error %+v, want code %s and a location", c.query, e, codeInvalidQuery)
+			}
+		}
+		if !found {
+			t.Errorf("%q: errors %v, want one containing %q", c.query, errs, c.msg)
+		}
+	}
+}
+
+func TestValidateGraphQLAccepts(t *testing.T) {
+	queries := []struct{ query, opName string }{
+		{nested(gqlMaxDepth - 2), ""}, // exactly gqlMaxDepth levels
+		{"query A { hello } query B { num(n: 1) }", "B"},
+		{"query ($n: Int!) { num(n: $n) }", ""},
+		{"{ hello(name: null) __typename item { __typename } }", ""},
+		{"{ item { ...A } } fragment A on Item { id child { ...B } } fragment B on Item { id }", ""},
+	}
+	for _, q := range queries {
+		doc, err := parseGraphQL(q.query)
+		if err != nil {
+			t.Errorf("%q: %v", q.query, err)
+			continue
+		}
+		op, errs := testSchema.validate(doc, q.opName)
+		if len(errs) > 0 {
+			t.Errorf("%q: %v", q.query, errs)
+		}
+		if q.opName != "" && op.name != q.opName {
+			t.Errorf("%q: picked operation %q, want %q", q.query, op.name, q.opName)
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Execution
+// -----------------------------------------------------------------------------
+
+func TestExecuteGraphQL(t *testing.T) {
+	cases := []struct{ name, query, vars, want string }{
+		{"argument default", "{ hello }", "", `{"hello":"hello world"}`},
+		{"literal argument", `{ hello(name: "go") }`, "", `{"hello":"hello go"}`},
+		{"aliases keep query order", `{ b: hello(name: "b") a: hello(name: "a") hello }`, "",
+			`{"b":"hello b","a":"hello a","hello":"hello world"}`},
+		{"variable", "query ($n: Int!) { num(n: $n) }", `{"n": 3}`, `{"num":3}`},
+		{"variable default", `query ($who: String = "var") { hello(name: $who) }`, "", `{"hello":"hello var"}`},
+		{"variable overrides default", `query ($who: String = "var") { hello(name: $who) }`, `{"who": "json"}`, `{"hello":"hello json"}`},
+		{"list variable", "query ($ns: [Int!]!) { sum(ns: $ns) }", `{"ns": [1, 2, 3]}`, `{"sum":6}`},
+		{"single value as a list", "query ($ns: [Int!]!) { sum(ns: $ns) }", `{"ns": 4}`, `{"sum":4}`},
+		{"list literal with a variable", "query ($n: Int!) { sum(ns: [1, $n]) }", `{"n": 10}`, `{"sum":11}`},
+		{"nested objects", "{ item { id child { id child { id } } } }", "", `{"item":{"id":1,"child":{"id":2,"child":{"id":3}}}}`},
+		{"list of objects", "{ items(count: 2) { id } }", "", `{"items":[{"id":0},{"id":1}]}`},
+		{"__typename", "{ __typename item { __typename id } }", "", `{"__typename":"Query","item":{"__typename":"Item","id":1}}`},
+		{"fragment spread", "{ item { ...F } } fragment F on Item { id child { id } }", "", `{"item":{"id":1,"child":{"id":2}}}`},
+		{"inline fragment", "{ ... on Query { hello } item { ... { id } } }", "", `{"hello":"hello world","item":{"id":1}}`},
+		{"same key merges selections", "{ item { id } item { child { id } } }", "", `{"item":{"id":1,"child":{"id":2}}}`},
+		{"fragment and field merge", "{ item { id ...F } } fragment F on Item { id child { id } }", "", `{"item":{"id":1,"child":{"id":2}}}`},
+		{"@skip literal", "{ a: hello @skip(if: true) b: hello @skip(if: false) }", "", `{"b":"hello world"}`},
+		{"@include literal", "{ a: hello @include(if: true) b: hello @include(if: false) }", "", `{"a":"hello world"}`},
+		{"@skip and @include variable", "query ($s: Boolean!) { a: hello @skip(if: $s) b: hello @include(if: $s) }",
+			`{"s": true}`, `{"b":"hello world"}`},
+		{"@skip variable false", "query ($s: Boolean!) { a: hello @skip(if: $s) b: hello @include(if: $s) }",
+			`{"s": false}`, `{"a":"hello world"}`},
+		{"@skip on a fragment spread", "query ($s: Boolean!) { hello ...F @skip(if: $s) } fragment F on Query { num(n: 1) }",
+			`{"s": true}`, `{"hello":"hello world"}`},
+		{"@include on an inline fragment", "{ hello ... @include(if: false) { num(n: 1) } }", "", `{"hello":"hello world"}`},
+	}
+	for _, c := range cases {
+		resp := execute(t, c.query, c.vars)
+		if len(resp.Errors) > 0 {
+			t.Errorf("%s: errors %v", c.name, resp.Errors)
+			continue
+		}
+		if got := dataJSON(t, resp); got != c.want {
+			t.Errorf("%s: %s, want %s", c.name, got, c.want)
+		}
+	}
+}
+
+func TestExecuteGraphQLVariableErrors(t *testing.T) {
+	cases := []struct{ query, vars, msg string }{
+		{"query ($n: Int!) { num(n: $n) }", "", "variable $n: expected a non-null Int!"},
+		{"query ($n: Int!) { num(n: $n) }", `{"n": null}`, "variable $n: expected a non-null Int!"},
+		{"query ($n: Int!) { num(n: $n) }", `{"n": "3"}`, "variable $n: expected Int"},
+		{"query ($n: Int!) { num(n: $n) }", `{"n": 3.5}`, "variable $n: expected Int"},
+		{"query ($n: Int!) { num(n: $n) }", `{"n": 1e12}`, "variable $n: expected Int"},
+		{"query ($s: Boolean!) { hello @skip(if: $s) }", `{"s": "yes"}`, "variable $s: expected Boolean"},
+		{"query ($ns: [Int!]!) { sum(ns: $ns) }", `{"ns": [1, null]}`, "variable $ns: expected a non-null Int!"},
+	}
+	for _, c := range cases {
+		resp := execute(t, c.query, c.vars)
+		if resp.Data != nil {
+			t.Errorf("%q with %s: ran, want a variable error", c.query, c.vars)
+			continue
+		}
+		if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, c.msg) ||
+			resp.Errors[0].Extensions["code"] != codeInvalidQuery {
+			t.Errorf("%q with %s: errors %v, want one %s error containing %q", c.query, c.vars, resp.Errors, codeInvalidQuery, c.msg)
+		}
+	}
+}
+
+func TestExecuteGraphQLFieldErrors(t *testing.T) {
+	resp := execute(t, `{ hello f: fail broken num(n: "x") item { id } }`, "")
+	if got, want := dataJSON(t, resp), `{"hello":"hello world","f":null,"broken":null,"num":null,"item":{"id":1}}`; got != want {
+		t.Errorf("data %s, want %s", got, want)
+	}
+	if len(resp.Errors) != 3 {
+		t.Fatalf("errors %v, want 3", resp.Errors)
+	}
+
+	// An *APIError keeps its code and details, and the error its path and location
+	fail := resp.Errors[0]
+	if fail.Message != "no such thing" || fail.Extensions["code"] != codeUnknownElement ||
+		fmt.Sprint(fail.Extensions["details"]) != "map[name:x]" {
+		t.Errorf("resolver APIError: %+v", fail)
+	}
+	if fmt.Sprint(fail.Path) != "[f]" || len(fail.Locations) != 1 || fail.Locations[0] != (gqlLocation{1, 9}) {
+		t.Errorf("resolver APIError path %v at %v, want [f] at 1:9", fail.Path, fail.Locations)
+	}
+
+	// Any other error has a message only
+	if broken := resp.Errors[1]; broken.Message != "plain failure" || broken.Extensions != nil || fmt.Sprint(broken.Path) != "[broken]" {
+		t.Errorf("plain resolver error: %+v", broken)
+	}
+
+	// Arguments of the wrong type fail the field, not the query
+	if num := resp.Errors[2]; num.Extensions["code"] != codeInvalidParameter || !strings.Contains(num.Message, `argument "n"`) {
+		t.Errorf("argument error: %+v", num)
+	}
+}
+
+func TestExecuteGraphQLErrorPathInList(t *testing.T) {
+	// Nulls inside lists report the index in the path
+	schema := newGQLSchema(
+		[]gqlScalar{{Name: "String", builtin: true}},
+		&gqlObjectType{Name: "Query", Fields: []*gqlFieldDef{
+			{Name: "rows", Type: "[Row!]!", Resolve: func(*gqlExec, interface{}, map[string]interface{}) (interface{}, error) {
+				return []interface{}{"ok", "bad"}, nil
+			}},
+		}},
+		&gqlObjectType{Name: "Row", Fields: []*gqlFieldDef{
+			{Name: "value", Type: "String", Resolve: func(_ *gqlExec, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+				if parent == "bad" {
+					return nil, errors.New("bad row")
+				}
+				return parent, nil
+			}},
+		}},
+	)
+	doc, err := parseGraphQL("{ rows { value } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, errs := schema.validate(doc, "")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	ex := &gqlExec{ctx: context.Background(), schema: schema, doc: doc, vars: map[string]interface{}{}}
+	data, _ := json.Marshal(ex.object(schema.byName["Query"], nil, op.sel, nil))
+	if string(data) != `{"rows":[{"value":"ok"},{"value":null}]}` {
+		t.Errorf("data %s", data)
+	}
+	if len(ex.errs) != 1 || fmt.Sprint(ex.errs[0].Path) != "[rows 1 value]" {
+		t.Errorf("errors %+v, want one at [rows 1 value]", ex.errs)
+	}
+}
+
+func TestExecuteGraphQLFieldLimit(t *testing.T) {
+	// items counts as one field and every id as another, so the last id is over the limit
+	resp := execute(t, fmt.Sprintf("{ items(count: %d) { id } }", gqlMaxFields), "")
+	if len(resp.Errors) != 1 {
+		t.Fatalf("%d errors, want 1 (reported once)", len(resp.Errors))
+	}
+	e := resp.Errors[0]
+	if e.Extensions["code"] != codeInvalidQuery || !strings.Contains(e.Message, fmt.Sprintf("more than %d fields", gqlMaxFields)) {
+		t.Errorf("limit error: %+v", e)
+	}
+	last := gqlMaxFields - 1
+	if fmt.Sprint(e.Path) != fmt.Sprintf("[items %d id]", last) {
+		t.Errorf("limit error at %v, want [items %d id]", e.Path, last)
+	}
+
+	var data struct {
+		Items []struct{ ID *int } `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(dataJSON(t, resp)), &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Items) != gqlMaxFields || data.Items[last-1].ID == nil || data.Items[last].ID != nil {
+		t.Errorf("want %d items with every id but the last resolved", gqlMaxFields)
+	}
+
+	// One field less fits
+	if resp := execute(t, fmt.Sprintf("{ items(count: %d) { id } }", gqlMaxFields-1), ""); len(resp.Errors) != 0 {
+		t.Errorf("%d fields: errors %v", gqlMaxFields, resp.Errors)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// HTTP handler (the real schema on a small catalog)
+// -----------------------------------------------------------------------------
+
+var loadGraphQLCatalog sync.Once
+
+// useTestCatalog activates a small catalog for the handler tests
+func useTestCatalog(t *testing.T) {
+	t.Helper()
+	loadGraphQLCatalog.Do(func() {
+		*warmupEnabled = false
+		findCache = newResponseCache(100, 1<<20)
+		el := func(name string, recipes ...[]string) recipeFinder.Element {
+			return recipeFinder.Element{Name: name, Recipes: recipes}
+		}
+		catalog := recipeFinder.Catalog{Tiers: []recipeFinder.Tier{
+			{Name: "Starting", Elements: []recipeFinder.Element{el("Air"), el("Earth"), el("Fire"), el("Water")}},
+			{Name: "1", Elements: []recipeFinder.Element{
+				el("Mud", []string{"Water", "Earth"}),
+				el("Lava", []string{"Earth", "Fire"}),
+			}},
+			{Name: "2", Elements: []recipeFinder.Element{
+				el("Brick", []string{"Mud", "Fire"}),
+				el("Stone", []string{"Lava", "Air"}),
+				el("Ghost"), // no recipe reaches it
+			}},
+		}}
+		raw, _ := json.Marshal(catalog)
+		activateCatalog(catalog, raw, time.Now())
+	})
+}
+
+// serveGraphQL sends a request to handleGraphQL and decodes the answer
+func serveGraphQL(t *testing.T, r *http.Request) (int, map[string]json.RawMessage, []gqlError) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleGraphQL(w, r)
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s %s: body %q: %v", r.Method, r.URL, w.Body.String(), err)
+	}
+	var errs []gqlError
+	if raw, ok := body["errors"]; ok {
+		if err := json.Unmarshal(raw, &errs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code, body, errs
+}
+
+func postGraphQL(t *testing.T, req graphqlRequest) (int, map[string]json.RawMessage, []gqlError) {
+	t.Helper()
+	b, _ := json.Marshal(req)
+	return serveGraphQL(t, httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(string(b))))
+}
+
+func TestGraphQLHandlerQueries(t *testing.T) {
+	useTestCatalog(t)
+
+	status, body, errs := postGraphQL(t, graphqlRequest{
+		Query: `query ($name: String!) {
+			element(name: $name) { name tier isBase recipes { a { name } b { name } } }
+			path(from: "Water", to: "Brick") { product { name } }
+			snapshot
+		}`,
+		Variables: map[string]interface{}{"name": "brick"}, // names resolve case-insensitively
+	})
+	if status != http.StatusOK || len(errs) > 0 {
+		t.Fatalf("status %d, errors %v", status, errs)
+	}
+	var data struct {
+		Element struct {
+			Name, Tier string
+			IsBase     bool
+			Recipes    []struct{ A, B struct{ Name string } }
+		}
+		Path     []struct{ Product struct{ Name string } }
+		Snapshot string
+	}
+	if err := json.Unmarshal(body["data"], &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Element.Name != "Brick" || data.Element.Tier != "2" || data.Element.IsBase || len(data.Element.Recipes) != 1 {
+		t.Errorf("element: %+v", data.Element)
+	}
+	if n := len(data.Path); n == 0 || data.Path[n-1].Product.Name != "Brick" {
+		t.Errorf("path: %+v", data.Path)
+	}
+	if data.Snapshot != catalogVersion {
+		t.Errorf("snapshot %q, want %q", data.Snapshot, catalogVersion)
+	}
+
+	// GET takes the same fields as query parameters
+	q := url.Values{
+		"query":         {"query A { snapshot } query B ($n: String!) { element(name: $n) { name } }"},
+		"operationName": {"B"},
+		"variables":     {`{"n": "Mud"}`},
+	}
+	status, body, errs = serveGraphQL(t, httptest.NewRequest(http.MethodGet, "/api/v1/graphql?"+q.Encode(), nil))
+	if status != http.StatusOK || len(errs) > 0 || string(body["data"]) != `{"element":{"name":"Mud"}}` {
+		t.Errorf("GET: status %d, data %s, errors %v", status, body["data"], errs)
+	}
+}
+
+func TestGraphQLHandlerFieldErrors(t *testing.T) {
+	useTestCatalog(t)
+
+	// Errors of single fields answer 200 with data and the domain error code
+	status, body, errs := postGraphQL(t, graphqlRequest{Query: `{
+		missing: element(name: "Nope") { name }
+		mud: element(name: "Mud") { name findRecipe(maxPaths: 0) { trees } }
+	}`})
+	if status != http.StatusOK {
+		t.Fatalf("status %d, want 200", status)
+	}
+	if got, want := string(body["data"]), `{"missing":null,"mud":{"name":"Mud","findRecipe":null}}`; got != want {
+		t.Errorf("data %s, want %s", got, want)
+	}
+	if len(errs) != 2 || errs[0].Extensions["code"] != codeUnknownElement || fmt.Sprint(errs[0].Path) != "[missing]" ||
+		errs[1].Extensions["code"] != codeInvalidParameter || fmt.Sprint(errs[1].Path) != "[mud findRecipe]" {
+		t.Errorf("errors %+v", errs)
+	}
+
+	// A search runs through the same code as /api/v1/find
+	_, body, errs = postGraphQL(t, graphqlRequest{Query: `{ element(name: "Brick") { findRecipe(algorithm: "dfs") { algorithm trees } } }`})
+	if len(errs) > 0 || !strings.Contains(string(body["data"]), `"algorithm":"dfs","trees":[{"name":"Brick"`) {
+		t.Errorf("findRecipe: data %s, errors %v", body["data"], errs)
+	}
+}
+
+func TestGraphQLHandlerRejects(t *testing.T) {
+	useTestCatalog(t)
+
+	post := func(body string) *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(body))
+	}
+	get := func(q url.Values) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/api/v1/graphql?"+q.Encode(), nil)
+	}
+	oversized := `{"query": "{ snapshot }", "variables": {"pad": "` + strings.Repeat("x", gqlMaxBody) + `"}}`
+
+	cases := []struct {
+		name string
+		req  *http.Request
+		msg  string
+	}{
+		{"invalid JSON body", post("{"), "invalid GraphQL request"},
+		{"body over the limit", post(oversized), "too large"},
+		{"missing query", post(`{"query": "  "}`), "missing query"},
+		{"GET without query", get(url.Values{}), "missing query"},
+		{"GET with malformed variables", get(url.Values{"query": {"{ snapshot }"}, "variables": {"[1]"}}), "variables must be a JSON object"},
+		{"syntax error", post(`{"query": "{ snapshot"}`), "syntax error"},
+		{"validation error", post(`{"query": "{ element { name } }"}`), `requires argument "name"`},
+		{"variable error", post(`{"query": "query ($n: String!) { element(name: $n) { name } }"}`), "variable $n"},
+	}
+	for _, c := range cases {
+		status, body, errs := serveGraphQL(t, c.req)
+		if status != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", c.name, status)
+		}
+		if _, ok := body["data"]; ok {
+			t.Errorf("%s: has data, want errors only", c.name)
+		}
+		if len(errs) == 0 || !strings.Contains(errs[0].Message, c.msg) || errs[0].Extensions["code"] != codeInvalidQuery {
+			t.Errorf("%s: errors %v, want an %s error containing %q", c.name, errs, codeInvalidQuery, c.msg)
+		}
+	}
+
+	// Other methods get the usual API error
+	w := httptest.NewRecorder()
+	handleGraphQL(w, httptest.NewRequest(http.MethodPut, "/api/v1/graphql", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, POST" ||
+		!strings.Contains(w.Body.String(), codeMethodNotAllowed) {
+		t.Errorf("PUT: status %d, Allow %q, body %s", w.Code, w.Header().Get("Allow"), w.Body)
+	}
+}
+
+func TestGraphQLSearchLimit(t *testing.T) {
+	useTestCatalog(t)
+	defer func(n int) { *batchMaxTargets = n }(*batchMaxTargets)
+	*batchMaxTargets = 2
+
+	fields := func(n int) string {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(&b, "s%d: findRecipe { algorithm } ", i)
+		}
+		return b.String()
+	}
+	cases := []struct {
+		query string
+		msg   string // "" when the query runs
+	}{
+		{`{ element(name: "Mud") { ` + fields(2) + `} }`, ""},
+		{`{ element(name: "Mud") { ` + fields(3) + `} }`, "more than 2 searches"},
+		// Every spread of a fragment counts
+		{`{ a: element(name: "Mud") { ...F } b: element(name: "Lava") { ...F } } fragment F on Element { ` + fields(2) + `}`,
+			"more than 2 searches"},
+		// One per item of a list has no fixed bound
+		{`{ elements(tier: "1") { findRecipe { algorithm } } }`, "inside a list"},
+		{`{ element(name: "Brick") { recipes { a { ... on Element { findRecipe { algorithm } } } } } }`, "inside a list"},
+	}
+	for _, c := range cases {
+		status, _, errs := postGraphQL(t, graphqlRequest{Query: c.query})
+		if c.msg == "" {
+			if status != http.StatusOK || len(errs) > 0 {
+				t.Errorf("%s: status %d, errors %v, want 200", c.query, status, errs)
+			}
+			continue
+		}
+		if status != http.StatusBadRequest || len(errs) != 1 || !strings.Contains(errs[0].Message, c.msg) {
+			t.Errorf("%s: status %d, errors %v, want one containing %q", c.query, status, errs, c.msg)
+		}
+	}
+}
+
+func TestGraphQLSchemaSDL(t *testing.T) {
+	w := httptest.NewRecorder()
+	handleGraphQLSchema(w, httptest.NewRequest(http.MethodGet, "/api/v1/graphql/schema", nil))
+	sdl := w.Body.String()
+	for _, want := range []string{
+		"scalar RecipeTree",
+		"type Query {",
+		"  element(name: String!): Element\n",
+		`  findRecipe(algorithm: String = "bfs", maxPaths: Int): SearchResult`,
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("schema SDL lacks %q", want)
+		}
+	}
+
+	// Every object type of the schema is defined
+	for _, typ := range graphQLSchema.types {
+		if !strings.Contains(sdl, "type "+typ.Name+" {") {
+			t.Errorf("schema SDL lacks type %s", typ.Name)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// backend/limits.go
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Per-IP rate limiting
+// -----------------------------------------------------------------------------
+
+// searchLimiter throttles the search endpoints per client IP (set up in main
+// from -rate-limit / -rate-burst; nil means unlimited)
+var searchLimiter *ipLimiter
+
+// limiterSweepInterval is how often buckets of idle clients are dropped
+const limiterSweepInterval = time.Minute
+
+// bucket is the token bucket of one client
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// ipLimiter is a token bucket per client IP: rate requests per second on
+// average, bursts of up to burst requests
+type ipLimiter struct {
+	rate, burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// newIPLimiter returns a limiter, or nil (no limit) if rate is 0
+func newIPLimiter(rate float64, burst int) *ipLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &ipLimiter{rate: rate, burst: float64(burst), buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// allow takes a token for ip. When there is none it returns false and how
+// long until the next one.
+func (l *ipLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > limiterSweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[ip] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops the buckets that have refilled completely (the client has been
+// idle long enough to be indistinguishable from a new one); the caller holds mu
+func (l *ipLimiter) sweep(now time.Time) {
+	for ip, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, ip)
+		}
+	}
+	l.lastSweep = now
+}
+
+// rateLimited answers 429 once a client exceeds its share of searchLimiter
+func rateLimited(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := searchLimiter.allow(clientIP(r), time.Now())
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, codeRateLimited,
+				"too many requests, slow down", map[string]interface{}{"retry_after_ms": wait.Milliseconds()})
+			return
+		}
+		h(w, r)
+	}
+}
+
+// liveSlots limits the live search sessions open at once (set up in main
+// from -max-live-sessions; nil means unlimited)
+var liveSlots chan struct{}
+
+// limitLiveSessions answers 503 instead of opening another live session when
+// every slot is taken. A session holds its slot until the socket closes.
+func limitLiveSessions(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if liveSlots != nil {
+			select {
+			case liveSlots <- struct{}{}:
+				defer func() { <-liveSlots }()
+			default:
+				w.Header().Set("Retry-After", "5")
+				writeError(w, http.StatusServiceUnavailable, codeServerBusy,
+					"too many live search sessions open, try again later",
+					map[string]int{"max_live_sessions": cap(liveSlots)})
+				return
+			}
+		}
+		h(w, r)
+	}
+}
+
+// clientIP is the address rate limits are counted against. Behind a proxy
+// (-trust-forwarded-for) that is the last X-Forwarded-For entry, the one
+// the proxy itself added; earlier entries can be forged by the client.
+func clientIP(r *http.Request) string {
+	if *trustForwardedFor {
+		if fwd := r.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+			parts := strings.Split(fwd[len(fwd)-1], ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// -----------------------------------------------------------------------------
+// Concurrent search guard
+// -----------------------------------------------------------------------------
+
+// searchSlots limits the searches running at once (set up in main from
+// -max-concurrent-searches; nil means unlimited)
+var searchSlots chan struct{}
+
+// limitConcurrency answers 503 instead of starting another search when
+// every slot is taken, so a burst of heavy queries can't starve the server
+func limitConcurrency(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if searchSlots != nil {
+			select {
+			case searchSlots <- struct{}{}:
+				defer func() { <-searchSlots }()
+			default:
+				w.Header().Set("Retry-After", "1")
+				writeError(w, http.StatusServiceUnavailable, codeServerBusy,
+					"too many searches running, try again shortly",
+					map[string]int{"max_concurrent_searches": cap(searchSlots)})
+				return
+			}
+		}
+		h(w, r)
+	}
+}
+
+// takeSearchSlots takes up to n more search slots without waiting, for a
+// request that runs several searches at once. It returns how many it got
+// (n when searches are unlimited) and a func that gives them back.
+func takeSearchSlots(n int) (int, func()) {
+	if searchSlots == nil {
+		return n, func() {}
+	}
+	got := 0
+take:
+	for got < n {
+		select {
+		case searchSlots <- struct{}{}:
+			got++
+		default:
+			break take
+		}
+	}
+	return got, func() {
+		for i := 0; i < got; i++ {
+			<-searchSlots
+		}
+	}
+}
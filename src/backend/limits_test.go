@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTakeSearchSlots(t *testing.T) {
+	defer func(old chan struct{}) { searchSlots = old }(searchSlots)
+
+	// Unlimited: every slot asked for
+	searchSlots = nil
+	if got, release := takeSearchSlots(3); got != 3 {
+		t.Errorf("unlimited: got %d slots, want 3", got)
+	} else {
+		release()
+	}
+
+	// One of three slots is held by the request itself: only two are left
+	searchSlots = make(chan struct{}, 3)
+	searchSlots <- struct{}{}
+	got, release := takeSearchSlots(5)
+	if got != 2 {
+		t.Errorf("got %d slots, want the 2 free ones", got)
+	}
+	if len(searchSlots) != 3 {
+		t.Errorf("%d slots taken, want all 3", len(searchSlots))
+	}
+	release()
+	if len(searchSlots) != 1 {
+		t.Errorf("%d slots taken after release, want the request's own 1", len(searchSlots))
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	defer func(old bool) { *trustForwardedFor = old }(*trustForwardedFor)
+
+	cases := []struct {
+		trust      bool
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{false, "10.0.0.5:4321", nil, "10.0.0.5"},
+		{false, "10.0.0.5:4321", []string{"1.2.3.4"}, "10.0.0.5"}, // not behind a proxy: the header is ignored
+		{false, "[::1]:4321", nil, "::1"},
+		{false, "no-port", nil, "no-port"},
+		{true, "172.18.0.3:4321", []string{"1.2.3.4"}, "1.2.3.4"},
+		{true, "172.18.0.3:4321", []string{"6.6.6.6, 1.2.3.4"}, "1.2.3.4"},   // the client forged the first entry
+		{true, "172.18.0.3:4321", []string{"6.6.6.6", "1.2.3.4"}, "1.2.3.4"}, // one header per hop
+		{true, "172.18.0.3:4321", nil, "172.18.0.3"},                         // no header: the peer address
+		{true, "172.18.0.3:4321", []string{" "}, "172.18.0.3"},
+	}
+	for _, c := range cases {
+		*trustForwardedFor = c.trust
+		r := httptest.NewRequest(http.MethodGet, "/api/find", nil)
+		r.RemoteAddr = c.remoteAddr
+		for _, v := range c.forwarded {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		if got := clientIP(r); got != c.want {
+			t.Errorf("trust=%v, remote %s, X-Forwarded-For %q: got %q, want %q", c.trust, c.remoteAddr, c.forwarded, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+// backend/live.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// Pacing limits of the live search socket
+const (
+	defaultLiveDelay = 200 * time.Millisecond
+	maxLiveDelay     = 5 * time.Second
+)
+
+// liveCommand is a control message sent by the client over the live socket:
+//
+//	{"command": "pause"} | {"command": "resume"} | {"command": "step"}
+//	{"command": "speed", "delay_ms": 100} | {"command": "stop"}
+type liveCommand struct {
+	Command string `json:"command"`
+	DelayMs int    `json:"delay_ms,omitempty"`
+}
+
+// liveMessage is a message sent to the client over the live socket
+type liveMessage struct {
+	Type         string                   `json:"type"` // start | step | paused | resumed | speed | done | error
+	Target       string                   `json:"target,omitempty"`
+	DelayMs      *int64                   `json:"delay_ms,omitempty"`
+	Event        *recipeFinder.StepEvent  `json:"event,omitempty"`
+	Found        bool                     `json:"found,omitempty"`
+	NodesVisited int                      `json:"nodes_visited,omitempty"`
+	Tree         *recipeFinder.RecipeNode `json:"tree,omitempty"`
+	Error        *APIError                `json:"error,omitempty"`
+}
+
+// handleLiveSearch serves /api/find/live?target=Name&delayMs=200 — a WebSocket
+// that runs the single-recipe BFS and pushes every push/pop/discovery as it
+// happens, paced by the server and controlled by the client's commands.
+func handleLiveSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	// ---------- parameter validation (before the upgrade, as plain JSON) ----------
+	target := q.Get("target")
+	if target == "" {
+		writeInvalidParameter(w, "target", "missing ?target=")
+		return
+	}
+	delay := defaultLiveDelay
+	if v := q.Get("delayMs"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeInvalidParameter(w, "delayMs", "delayMs must be a non-negative integer")
+			return
+		}
+		delay = clampLiveDelay(n)
+	}
+
+	// The session may be paused for a long time, so work on the current
+	// graph instead of holding catalogMu (the graph is never mutated in place)
+	catalogMu.RLock()
+	name, ok := recipeFinder.GlobalNameResolver.Resolve(target)
+	if !ok {
+		writeUnknownElement(w, target)
+		catalogMu.RUnlock()
+		return
+	}
+	graph := recipeFinder.GlobalIndexedGraph
+	catalogMu.RUnlock()
+
+	// Browsers don't apply CORS to WebSockets, so check the origin here
+	server := websocket.Server{
+		Handshake: func(_ *websocket.Config, r *http.Request) error {
+			if origin := r.Header.Get("Origin"); origin != "" && !corsAllowed(origin) {
+				return fmt.Errorf("origin %q not allowed", origin)
+			}
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			runLiveSearch(ws, name, graph, delay)
+		},
+	}
+	clearDeadlines(w)      // a session may stay paused up to -live-idle-timeout
+	server.ServeHTTP(w, r) // returns once the session is over
+
+}
+
+// runLiveSearch drives one live search session until the search finishes,
+// the client sends "stop", the connection drops or the session stays paused
+// without a command for -live-idle-timeout
+func runLiveSearch(ws *websocket.Conn, target string, graph recipeFinder.IndexedGraph, delay time.Duration) {
+	defer ws.Close()
+
+	// Hijacked connections aren't drained on shutdown, so end the session then
+	ctx, cancel := context.WithCancel(serverCtx)
+	defer cancel()
+
+	send := func(m liveMessage) bool {
+		if err := websocket.JSON.Send(ws, m); err != nil {
+			cancel()
+			return false
+		}
+		return true
+	}
+	delayMs := func() *int64 {
+		ms := delay.Milliseconds()
+		return &ms
+	}
+
+	// ---------- client commands ----------
+	commands := make(chan liveCommand)
+	go func() {
+		defer cancel() // a read error means the client is gone
+		for {
+			var cmd liveCommand
+			if err := websocket.JSON.Receive(ws, &cmd); err != nil {
+				return
+			}
+			select {
+			case commands <- cmd:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// ---------- the search itself, blocked on every event until it is sent ----------
+	events := make(chan recipeFinder.StepEvent)
+	type result struct {
+		prev  recipeFinder.ProductToIngredients
+		nodes int
+	}
+	results := make(chan result, 1)
+	go func() {
+		defer close(events)
+		prev, nodes := recipeFinder.IndexedBFSTrace(ctx, target, graph, recipeFinder.NewStreamTracer(graph, func(ev recipeFinder.StepEvent) {
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+			}
+		}))
+		results <- result{prev, nodes}
+	}()
+
+	// finish sends the final tree once the search has run out of events
+	finish := func() {
+		res := <-results
+		// Any fallback search runs on the same snapshot as the search itself
+		tree := recipeFinder.BuildTreeOn(ctx, graph, target, res.prev)
+
+		found := hasRecipe(target, tree)
+		msg := liveMessage{Type: "done", Target: target, Found: found, NodesVisited: res.nodes}
+		if found {
+			msg.Tree = tree
+		}
+		send(msg)
+	}
+
+	// next forwards one event; false once the session is over
+	next := func() bool {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				finish()
+				return false
+			}
+			return send(liveMessage{Type: "step", Event: &ev})
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if !send(liveMessage{Type: "start", Target: target, DelayMs: delayMs()}) {
+		return
+	}
+
+	// One timer paces the steps while running and, while paused, closes the
+	// session after -live-idle-timeout; it restarts after every command or step
+	paused := false
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	restart := func() {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if !paused {
+			timer.Reset(delay)
+		} else if *liveIdleTimeout > 0 {
+			timer.Reset(*liveIdleTimeout)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case cmd := <-commands:
+			switch cmd.Command {
+			case "pause":
+				paused = true
+				send(liveMessage{Type: "paused"})
+			case "resume":
+				paused = false
+				send(liveMessage{Type: "resumed"})
+			case "step":
+				if !next() {
+					return
+				}
+			case "speed":
+				delay = clampLiveDelay(cmd.DelayMs)
+				send(liveMessage{Type: "speed", DelayMs: delayMs()})
+			case "stop":
+				return
+			default:
+				send(liveMessage{Type: "error", Error: &APIError{
+					Code:    codeInvalidParameter,
+					Message: "unknown command " + strconv.Quote(cmd.Command) + ", use pause, resume, step, speed or stop",
+				}})
+			}
+
+		case <-timer.C:
+			if paused {
+				send(liveMessage{Type: "error", Error: &APIError{
+					Code:    codeTimeout,
+					Message: fmt.Sprintf("session paused for more than %s without a command, closing", *liveIdleTimeout),
+				}})
+				return
+			}
+			if !next() {
+				return
+			}
+		}
+		restart()
+	}
+}
+
+// clampLiveDelay converts a client supplied delay to a duration within limits
+func clampLiveDelay(ms int) time.Duration {
+	d := time.Duration(ms) * time.Millisecond
+	if d < 0 {
+		return 0
+	}
+	if d > maxLiveDelay {
+		return maxLiveDelay
+	}
+	return d
+}
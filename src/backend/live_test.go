@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// dialLive opens a live search session on a test server
+func dialLive(t *testing.T, query string) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(handleLiveSearch))
+	t.Cleanup(srv.Close)
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/find/live?"+query, "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	ws.SetDeadline(time.Now().Add(10 * time.Second))
+	return ws
+}
+
+func TestLiveSearchRunsToTheTree(t *testing.T) {
+	useTestCatalog(t)
+	ws := dialLive(t, "target=brick&delayMs=0")
+
+	steps := 0
+	for {
+		var msg liveMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			t.Fatalf("after %d steps: %v", steps, err)
+		}
+		switch msg.Type {
+		case "start":
+			if msg.Target != "Brick" {
+				t.Errorf("start for %q, want Brick", msg.Target)
+			}
+		case "step":
+			steps++
+		case "done":
+			if !msg.Found || msg.Tree == nil || msg.Tree.Name != "Brick" || len(msg.Tree.Children) != 2 {
+				t.Errorf("done: found %v, tree %+v", msg.Found, msg.Tree)
+			}
+			if steps == 0 || msg.NodesVisited == 0 {
+				t.Errorf("done after %d steps and %d nodes", steps, msg.NodesVisited)
+			}
+			return
+		default:
+			t.Fatalf("unexpected %s message: %+v", msg.Type, msg)
+		}
+	}
+}
+
+func TestLiveSearchClosesIdlePausedSession(t *testing.T) {
+	useTestCatalog(t)
+	defer func(old time.Duration) { *liveIdleTimeout = old }(*liveIdleTimeout)
+	*liveIdleTimeout = 50 * time.Millisecond
+
+	ws := dialLive(t, "target=Brick&delayMs=5000")
+	var msg liveMessage
+	if err := websocket.JSON.Receive(ws, &msg); err != nil || msg.Type != "start" {
+		t.Fatalf("first message %+v, %v", msg, err)
+	}
+	if err := websocket.JSON.Send(ws, liveCommand{Command: "pause"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"paused", "error"} {
+		msg = liveMessage{}
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			t.Fatalf("waiting for %s: %v", want, err)
+		}
+		if msg.Type != want {
+			t.Fatalf("got %s message %+v, want %s", msg.Type, msg, want)
+		}
+	}
+	if msg.Error == nil || msg.Error.Code != codeTimeout {
+		t.Errorf("idle error %+v, want code %s", msg.Error, codeTimeout)
+	}
+	if err := websocket.JSON.Receive(ws, &msg); err == nil {
+		t.Errorf("session still open after the idle timeout: %+v", msg)
+	}
+}
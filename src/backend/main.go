@@ -2,14 +2,18 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
@@ -19,9 +23,10 @@ import (
 // Constants
 // -----------------------------------------------------------------------------
 const (
-	jsonDir  = "json"                   // directory for recipe.json & query results
-	jsonFile = jsonDir + "/recipe.json" // full path to recipe.json
-	svgDir   = "svgs"                   // directory for SVG icons for frontend
+	jsonDir   = "json"                    // directory for recipe.json & the recipe table
+	jsonFile  = jsonDir + "/recipe.json"  // full path to recipe.json
+	aliasFile = jsonDir + "/aliases.json" // optional extra element name aliases
+	svgDir    = "svgs"                    // directory for SVG icons for frontend
 )
 
 // -----------------------------------------------------------------------------
@@ -34,78 +39,159 @@ var (
 	downloadSVGs = flag.Bool("download-svgs", false, "Download SVGs during scrape")
 	// HTTP server address & port
 	addr = flag.String("addr", ":8080", "listen address")
+	// HTTP server limits. Searches and streams extend the write timeout by
+	// their own time budget, WebSocket sessions lift the timeouts.
+	readTimeout       = flag.Duration("read-timeout", 15*time.Second, "max time to read a request (0 = none)")
+	readHeaderTimeout = flag.Duration("read-header-timeout", 5*time.Second, "max time to read request headers")
+	writeTimeout      = flag.Duration("write-timeout", 30*time.Second, "max time to write a response, on top of a search's budget (0 = none)")
+	idleTimeout       = flag.Duration("idle-timeout", 120*time.Second, "max time a keep-alive connection stays idle")
+	maxHeaderBytes    = flag.Int("max-header-bytes", 1<<20, "max size of request headers in bytes")
+	// Token required by admin endpoints (POST /api/scrape); without one they are disabled
+	adminToken = flag.String("admin-token", "", "bearer token for admin endpoints (empty disables them)")
+	// Per-IP rate limit of the search endpoints, and how many searches may run at once
+	rateLimit             = flag.Float64("rate-limit", 5, "search requests per second per client IP (0 disables)")
+	rateBurst             = flag.Int("rate-burst", 20, "search requests a client IP may burst above -rate-limit")
+	trustForwardedFor     = flag.Bool("trust-forwarded-for", false, "take the client IP from X-Forwarded-For (only behind a trusted proxy)")
+	maxConcurrentSearches = flag.Int("max-concurrent-searches", 16, "searches running at once before new ones get 503 (0 disables)")
+	// Live search sockets hold a search open for as long as the client likes, so they are capped on their own
+	maxLiveSessions = flag.Int("max-live-sessions", 16, "live search sessions open at once before new ones get 503 (0 disables)")
+	liveIdleTimeout = flag.Duration("live-idle-timeout", 5*time.Minute, "max time a live search session may stay paused without a command (0 = none)")
+	// CORS: which other origins may call the API, with which methods and request headers
+	corsOrigins = flag.String("cors-origins", "*", "comma separated origins allowed to call the API (* = any, empty = none)")
+	corsMethods = flag.String("cors-methods", "GET,POST,OPTIONS", "comma separated methods allowed for cross-origin requests")
+	corsHeaders = flag.String("cors-headers", "Content-Type,Authorization,If-None-Match,X-Request-ID", "comma separated request headers allowed for cross-origin requests")
+	// How long in-flight requests may take to finish after SIGINT/SIGTERM
+	shutdownTimeout = flag.Duration("shutdown-timeout", 30*time.Second, "grace period for in-flight requests on shutdown")
+	// If -refresh is set, re-scrape on that interval in the background (e.g. 24h)
+	refreshEvery = flag.Duration("refresh", 0, "re-scrape interval (0 disables)")
+	// Sanity limits a scheduled refresh must pass before its catalog is swapped in
+	refreshMaxRemoved        = flag.Float64("refresh-max-removed", 0.05, "max fraction of elements a refresh may remove")
+	refreshMaxRecipesRemoved = flag.Float64("refresh-max-recipes-removed", 0.05, "max fraction of recipes a refresh may remove")
+	// Minimum catalog size a scrape must reach, otherwise it is treated as failed
+	scrapeMinTiers    = flag.Int("scrape-min-tiers", recipeFinder.DefaultScrapeLimits.MinTiers, "min tiers a scrape must find (0 disables)")
+	scrapeMinElements = flag.Int("scrape-min-elements", recipeFinder.DefaultScrapeLimits.MinElements, "min elements a scrape must find (0 disables)")
+	scrapeMinRecipes  = flag.Int("scrape-min-recipes", recipeFinder.DefaultScrapeLimits.MinRecipes, "min recipes a scrape must find (0 disables)")
+	// Default time budget of a search, and the most a client may ask for with ?timeoutMs=
+	searchTimeout    = flag.Duration("search-timeout", 10*time.Second, "default search time budget")
+	maxSearchTimeout = flag.Duration("max-search-timeout", 60*time.Second, "max search time budget a client may request")
+	// Goroutines each parallel multi-path search may use
+	searchWorkers = flag.Int("search-workers", 0, "workers per parallel search (0 = one per CPU)")
+	// Size and parallelism of POST /api/find/batch
+	batchMaxTargets = flag.Int("batch-max-targets", 100, "max targets in one batch request, and findRecipe fields in one GraphQL query")
+	batchWorkers    = flag.Int("batch-workers", 4, "targets of a batch request searched at once, each taking a search slot (see -max-concurrent-searches)")
+	// Size limits of the in-memory /api/find response cache (0 disables it)
+	cacheEntries = flag.Int("cache-entries", 256, "max cached /api/find responses (0 disables the cache)")
+	cacheBytes   = flag.Int("cache-bytes", 64<<20, "max total size of cached /api/find responses in bytes")
+	// Precompute the single-recipe answers of every element after loading a catalog
+	warmupEnabled = flag.Bool("warmup", true, "precompute the recipe table of every element in the background")
+	warmupWorkers = flag.Int("warmup-workers", 0, "workers computing the recipe table (0 = one per CPU)")
+	// Log verbosity (debug shows the tree fallback diagnostics) and output format
+	logLevel  = flag.String("log-level", "info", "log level: debug, info, warn or error")
+	logFormat = flag.String("log-format", "text", "log format: text or json")
 )
 
 func main() {
+	// Environment variables first, so the command line overrides them
+	flag.Usage = usage
+	if err := applyEnv(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 	flag.Parse() // parse all flags above
 
-	var rawJSON []byte
-	var err error
+	if err := setupLogging(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// SIGINT/SIGTERM cancel scrapes and live sessions, then drain the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serverCtx = ctx
+
+	findCache = newResponseCache(*cacheEntries, *cacheBytes)
+	searchLimiter = newIPLimiter(*rateLimit, *rateBurst)
+	if *maxConcurrentSearches > 0 {
+		searchSlots = make(chan struct{}, *maxConcurrentSearches)
+	}
+	if *maxLiveSessions > 0 {
+		liveSlots = make(chan struct{}, *maxLiveSessions)
+	}
+	if *adminToken == "" {
+		slog.Info("admin endpoints disabled (no -admin-token)")
+	}
+
+	loadAliases() // extra element name aliases, if json/aliases.json exists
 
 	// ---------------------------------------------------------------------
 	// 1) Run scraper if requested
 	// ---------------------------------------------------------------------
 	if *doScrape {
-		// Scrape and assign directly to global catalog
-		recipeFinder.GlobalCatalog, err = recipeFinder.ScrapeAll(*downloadSVGs)
-
+		t0 := time.Now()
+		catalog, report, err := recipeFinder.ScrapeAll(serverCtx, *downloadSVGs, scrapeLimits())
+		logScrapeReport(report)
+		observeScrape("startup", t0, err)
 		if err != nil {
-			log.Fatalf("scrape failed: %v", err)
+			fatal("scrape failed", "error", err)
 		}
 
-		os.MkdirAll(jsonDir, 0o755) // ensure directory exists
-		rawJSON, _ = json.MarshalIndent(recipeFinder.GlobalCatalog, "", "  ")
-		if err := os.WriteFile(jsonFile, rawJSON, 0o644); err != nil {
-			log.Fatal(err)
+		// Sort, save to recipe.json and build the indexed graph
+		if err := installCatalog(catalog); err != nil {
+			fatal("installing scraped catalog failed", "error", err)
 		}
 
-		log.Printf("wrote %s", jsonFile)
+		slog.Info("catalog written", "path", jsonFile)
 	} else {
 		// ---------------------------------------------------------------------
 		// 2) Read existing recipe.json
 		// ---------------------------------------------------------------------
-		rawJSON, err = os.ReadFile(jsonFile)
-		// if err != nil {
-		// 	log.Fatalf("cannot read %s: %v\nRun with -scrape first.", jsonFile, err)
-		// }
-
-		// // ---------------------------------------------------------------------
-		// // 3) Parse JSON → Catalog struct (only if we didn't just scrape)
-		// // ---------------------------------------------------------------------
-		// if err := json.Unmarshal(rawJSON, &recipeFinder.GlobalCatalog); err != nil {
-		// 	log.Fatalf("invalid JSON: %v", err)
-		// }
-	}
-
-	// Sort tiers in catalog - "Starting" first, then numeric tiers in order
-	sortCatalogTiers(&recipeFinder.GlobalCatalog)
+		raw, err := os.ReadFile(jsonFile)
+		if err != nil {
+			slog.Warn("no catalog loaded (run with -scrape or POST /api/scrape)", "path", jsonFile, "error", err)
+		} else {
+			// -----------------------------------------------------------------
+			// 3) Parse JSON → Catalog struct (only if we didn't just scrape)
+			// -----------------------------------------------------------------
+			var catalog recipeFinder.Catalog
+			if err := json.Unmarshal(raw, &catalog); err != nil {
+				fatal("invalid catalog JSON", "path", jsonFile, "error", err)
+			}
 
-	recipeFinder.InitElementTiers(recipeFinder.GlobalCatalog)
+			// Sort tiers, init tier cache and build the indexed graph.
+			// recipe.json was last written by a scrape, so its mtime is the scrape time.
+			var scrapedAt time.Time
+			if info, err := os.Stat(jsonFile); err == nil {
+				scrapedAt = info.ModTime()
+			}
+			activateCatalog(catalog, raw, scrapedAt)
+		}
+	}
 
 	// ---------------------------------------------------------------------
-	// 4) Build indexed graph for fast searches and save globally
+	// 4) Periodically re-scrape in the background if requested
 	// ---------------------------------------------------------------------
-	indexedGraph := recipeFinder.BuildIndexedGraph(recipeFinder.GlobalCatalog)
-	recipeFinder.GlobalIndexedGraph = indexedGraph // can be accessed by other packages
+	if *refreshEvery > 0 {
+		go refreshLoop(*refreshEvery)
+	}
 
 	// ---------------------------------------------------------------------
-	// 5) Static endpoint: /api/recipes — send raw catalog to frontend
+	// 5) Static endpoint: /api/v1/recipes — send raw catalog to frontend
 	// ---------------------------------------------------------------------
-	http.HandleFunc("/api/recipes", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		if r.Method == http.MethodOptions {
-			w.Header().Set("Access-Control-Allow-Methods", "GET,OPTIONS")
-			return
-		}
+	// Every /api/v1 route is also served under /api for older clients
+	route("/recipes", func(w http.ResponseWriter, r *http.Request) {
+		catalogMu.RLock()
+		raw := rawJSON
+		catalogMu.RUnlock()
+
 		w.Header().Set("Content-Type", "application/json")
-		w.Write(rawJSON)
+		w.Write(raw)
 	})
 
 	// ---------------------------------------------------------------------
 	// 6) Static file server for SVG icons (/svgs/...)
 	// ---------------------------------------------------------------------
 	wd, _ := os.Getwd()
-	log.Printf("Current working directory: %s", wd)
+	slog.Debug("working directory", "path", wd)
 
 	var svgPath string
 	if filepath.Base(wd) == "backend" {
@@ -114,195 +200,116 @@ func main() {
 		svgPath = filepath.Join(wd, svgDir)
 	}
 	if _, err := os.Stat(svgPath); os.IsNotExist(err) {
-		log.Fatalf("SVG directory not found at: %s", svgPath)
+		fatal("SVG directory not found", "path", svgPath)
 	}
-	log.Printf("Serving SVGs from: %s", svgPath)
+	slog.Info("serving SVGs", "path", svgPath)
 	http.Handle("/svgs/", http.StripPrefix("/svgs/", http.FileServer(http.Dir(svgPath))))
 
 	// ---------------------------------------------------------------------
-	// 7) Recipe search endpoint: /api/find?target=Name&maxPaths=5&multi=true
+	// 7) Recipe search endpoint: /api/v1/find?target=Name&maxPaths=5&multi=true&timeoutMs=10000
 	// ---------------------------------------------------------------------
-	type FindResponse struct {
-		Tree         interface{} `json:"tree"`
-		DurationMs   float64     `json:"duration_ms"`
-		Algorithm    string      `json:"algorithm"`
-		NodesVisited int         `json:"nodes_visited"`
-		SearchSteps  interface{} `json:"search_steps,omitempty"` // Use interface{} for flexibility
-	}
-
-	http.HandleFunc("/api/find", func(w http.ResponseWriter, r *http.Request) {
-		// ---------- parameter validation ----------
-		target := r.URL.Query().Get("target")
-		if target == "" {
-			http.Error(w, "missing ?target=", http.StatusBadRequest)
-			return
-		}
-
-		// maxPaths (default 5)
-		maxPaths := int64(5)
-		if v := r.URL.Query().Get("maxPaths"); v != "" {
-			if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
-				maxPaths = n
-			}
-		}
-		// multi=true/false
-		multi := true
-		if v := r.URL.Query().Get("multi"); v != "" {
-			multi = v == "true"
-		}
-		// algorithm=bfs|dfs|bidirectional
-		algo := r.URL.Query().Get("algorithm")
-		if algo == "" {
-			algo = "bfs"
-		}
+	// Searches are rate limited per client IP and capped in number
+	route("/find", rateLimited(limitConcurrency(handleFind)))
 
-		resp := FindResponse{Algorithm: algo}
-		t0 := time.Now()
-
-		// ---------- choose algorithm ----------
-		switch algo {
-		//-----------------------------------------------------------------
-		case "dfs":
-			recipeFinder.BuildReverseIndex(recipeFinder.GlobalIndexedGraph)
-			if multi {
-				// Get N unique paths (multi DFS)
-				effectiveMaxPaths := int(maxPaths) * 2
-				steps, nodes := recipeFinder.RangeDFSPaths(target, effectiveMaxPaths, recipeFinder.GlobalIndexedGraph)
-				resp.NodesVisited = nodes
-				trees := stepsToTrees(target, steps)
-
-				// Apply tree-based deduplication (just like in BFS)
-				if len(trees) > 0 {
-					trees = recipeFinder.DeduplicateRecipeTrees(trees)
-				}
-
-				resp.Tree = trees
-			} else {
-				// Single path (single DFS)
-				rec, nodes := recipeFinder.DFSBuildTargetToBase(target, recipeFinder.GlobalIndexedGraph)
-				resp.NodesVisited = nodes
-				resp.Tree = recipeFinder.BuildTree(target, rec)
-			}
+	// Same search, streamed tree by tree as Server-Sent Events
+	route("/find/stream", rateLimited(limitConcurrency(handleFindStream)))
 
-		//-----------------------------------------------------------------
-		case "bidirectional": // placeholder
-			if multi {
-				resp.Tree = []*recipeFinder.RecipeNode{}
-			} else {
-				prev, _, nodes := recipeFinder.IndexedBFSBuild(target, recipeFinder.GlobalIndexedGraph)
-				resp.NodesVisited = nodes
-				resp.Tree = recipeFinder.BuildTree(target, prev)
-			}
-
-		//-----------------------------------------------------------------
-		default: // bfs
-			if multi {
-				// Get multiple paths using the new target→base approach
-				completePaths, searchSteps, nodes := recipeFinder.ReversedMultiPathBFSParallel(target, recipeFinder.GlobalIndexedGraph, int(maxPaths) * 5)
-				
-				resp.NodesVisited = nodes
-				resp.SearchSteps = searchSteps // Store search steps for visualization
-					
-				// Convert complete paths to trees
-				printed := map[string]bool{}
-				var trees []*recipeFinder.RecipeNode
-					
-				// Each complete path is already a ProductToIngredients map
-				for _, path := range completePaths {
-					tree := recipeFinder.BuildTree(target, path)
-					
-					// Deduplicate while building
-					key, _ := json.Marshal(tree)
-					if !printed[string(key)] {
-						printed[string(key)] = true
-						trees = append(trees, tree)
-					}
-				}
-					
-				if len(trees) > 0 {
-					// Apply tree-based deduplication as a final step
-					trees = recipeFinder.DeduplicateRecipeTrees(trees)
-					resp.Tree = trees
-				}
-				resp.Tree = trees
-			} else {
-				// Single path BFS (unchanged)
-				prev, searchSteps, nodes := recipeFinder.IndexedBFSBuild(target, recipeFinder.GlobalIndexedGraph)
-				resp.NodesVisited = nodes
-				resp.Tree = recipeFinder.BuildTree(target, prev)
-				resp.SearchSteps = searchSteps
-			}
-		}
+	// Many targets with shared options in one request; the batch takes a
+	// single search slot and runs its targets on its own bounded pool
+	route("/find/batch", rateLimited(limitConcurrency(handleFindBatch)))
 
-		// ---------- write response ----------
-
-		resp.DurationMs = float64(time.Since(t0).Microseconds()) / 1000.0
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(resp)
-
-		// save to file for easy checking/debugging
-		raw, _ := json.MarshalIndent(resp, "", "  ")
-		os.MkdirAll(jsonDir, 0o755)
-		_ = os.WriteFile(filepath.Join(jsonDir, "queryResult.json"), raw, 0o644)
-	})
+	// Live single-recipe BFS over WebSocket: step events with pause/resume/step
+	// (a live session may stay open and paused for long, so instead of a search
+	// slot it takes one of -max-live-sessions, and idle paused sessions end)
+	route("/find/live", rateLimited(limitLiveSessions(handleLiveSearch)))
 
 	// ---------------------------------------------------------------------
-	// 8) Recipe scrape endpoint: /api/scrape
+	// 8) Recipe scrape endpoint: /api/v1/scrape (admin only)
 	// ---------------------------------------------------------------------
-	http.HandleFunc("/api/scrape", func(w http.ResponseWriter, r *http.Request) {
+	route("/scrape", requireAdmin(func(w http.ResponseWriter, r *http.Request) {
 		// Only allow POST requests
 		if r.Method != http.MethodPost {
-			w.Header().Set("Allow", "POST")
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			writeMethodNotAllowed(w, http.MethodPost)
 			return
 		}
 
-		log.Println("Scrape requested via API")
+		logger := recipeFinder.Logger(r.Context())
+		logger.Info("scrape requested via API")
+
+		// Only one scrape (manual or scheduled) may run at a time
+		scrapeMu.Lock()
+		defer scrapeMu.Unlock()
 
 		// Run the same scraping code as with the -scrape flag
-		catalog, err := recipeFinder.ScrapeAll(*downloadSVGs)
+		t0 := time.Now()
+		catalog, report, err := recipeFinder.ScrapeAll(serverCtx, *downloadSVGs, scrapeLimits())
+		logScrapeReport(report)
+		observeScrape("api", t0, err)
 		if err != nil {
-			log.Printf("API scrape failed: %v", err)
-			http.Error(w, "Failed to scrape data: "+err.Error(), http.StatusInternalServerError)
+			logger.Error("API scrape failed", "error", err)
+			writeError(w, http.StatusInternalServerError, codeScrapeFailed,
+				"Failed to scrape data: "+err.Error(),
+				map[string]interface{}{"warnings": report.Warnings})
 			return
 		}
 
-		// Update global catalog
-		recipeFinder.GlobalCatalog = catalog
-
-		// Sort catalog tiers
-		sortCatalogTiers(&recipeFinder.GlobalCatalog)
-
-		// Save to file
-		os.MkdirAll(jsonDir, 0o755)
-		rawJSON, _ = json.MarshalIndent(recipeFinder.GlobalCatalog, "", "  ")
-		if err := os.WriteFile(jsonFile, rawJSON, 0o644); err != nil {
-			log.Printf("Failed to write scraped data: %v", err)
-			http.Error(w, "Failed to save scraped data", http.StatusInternalServerError)
+		// Save to file and rebuild the indexed graph with the new data
+		if err := installCatalog(catalog); err != nil {
+			logger.Error("failed to write scraped data", "error", err)
+			writeError(w, http.StatusInternalServerError, codeScrapeFailed, "Failed to save scraped data", nil)
 			return
 		}
 
-		// Re-initialize with new data
-		recipeFinder.InitElementTiers(recipeFinder.GlobalCatalog)
-
-		// Rebuild indexed graph
-		indexedGraph = recipeFinder.BuildIndexedGraph(recipeFinder.GlobalCatalog)
-		recipeFinder.GlobalIndexedGraph = indexedGraph
-
 		// Return success response
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"status":         "success",
-			"message":        "Scraping completed successfully",
-			"elements_count": len(catalog.Tiers),
+		json.NewEncoder(w).Encode(ScrapeResponse{
+			Status:        "success",
+			Message:       "Scraping completed successfully",
+			ElementsCount: len(catalog.Tiers),
+			Warnings:      report.Warnings,
 		})
-	})
+	}))
+
+	// ---------------------------------------------------------------------
+	// 9) Element autocomplete endpoint: /api/v1/elements?q=Name&limit=10
+	// ---------------------------------------------------------------------
+	route("/elements", handleElements)
+
+	// ---------------------------------------------------------------------
+	// 10) Element detail endpoint: /api/v1/element/{name}
+	// ---------------------------------------------------------------------
+	route("/element/", handleElementDetail)
+
+	// ---------------------------------------------------------------------
+	// 11) GraphQL over the element graph: /api/v1/graphql (GET or POST)
+	// ---------------------------------------------------------------------
+	// findRecipe fields run searches, so a query is limited like one
+	route("/graphql", rateLimited(limitConcurrency(handleGraphQL)))
+	http.HandleFunc(apiV1+"/graphql/schema", handleGraphQLSchema)
+
+	// ---------------------------------------------------------------------
+	// 12) Health & status endpoints: /health, /ready, /api/v1/status
+	// ---------------------------------------------------------------------
+	http.HandleFunc("/health", handleHealth) // liveness: the process is serving
+	http.HandleFunc("/ready", handleReady)   // readiness: a catalog is loaded and searchable
+	route("/status", handleStatus)
+
+	// API description: /api/v1/openapi.json
+	http.HandleFunc(apiV1+"/openapi.json", handleOpenAPI)
+
+	// ---------------------------------------------------------------------
+	// 13) Prometheus metrics: /metrics
+	// ---------------------------------------------------------------------
+	http.HandleFunc("/metrics", handleMetrics)
 
 	// ---------------------------------------------------------------------
-	// 9) Run server
+	// 14) Run server
 	// ---------------------------------------------------------------------
-	log.Printf("listening on %s…", *addr)
-	log.Fatal(http.ListenAndServe(*addr, nil))
+	slog.Info("listening", "addr", *addr)
+	// Every route gets CORS headers and a request ID
+	if err := serve(serverCtx, newServer(withCORS(withRequestID(http.DefaultServeMux)))); err != nil {
+		fatal("server failed", "error", err)
+	}
 }
 
 /*
@@ -310,7 +317,7 @@ func main() {
 
 	Helper transform: steps -> tree slice (used in multi-DFS/BFS)
 */
-func stepsToTrees(target string, steps []recipeFinder.RecipeStep) []*recipeFinder.RecipeNode {
+func stepsToTrees(ctx context.Context, g recipeFinder.IndexedGraph, target string, steps []recipeFinder.RecipeStep) []*recipeFinder.RecipeNode {
 	var trees []*recipeFinder.RecipeNode
 	printed := map[string]bool{}
 	for _, step := range steps {
@@ -320,7 +327,7 @@ func stepsToTrees(target string, steps []recipeFinder.RecipeStep) []*recipeFinde
 				single[p[2]] = recipeFinder.RecipeStep{Combo: recipeFinder.IngredientCombo{A: p[0], B: p[1]}}
 			}
 		}
-		tree := recipeFinder.BuildTree(target, single)
+		tree := recipeFinder.BuildTreeOn(ctx, g, target, single)
 		key, _ := json.Marshal(tree)
 		if !printed[string(key)] {
 			printed[string(key)] = true
@@ -330,7 +337,7 @@ func stepsToTrees(target string, steps []recipeFinder.RecipeStep) []*recipeFinde
 	return trees
 }
 
-func infosToTrees(target string, infos []recipeFinder.RecipeStep, printed map[string]bool) []*recipeFinder.RecipeNode {
+func infosToTrees(ctx context.Context, target string, infos []recipeFinder.RecipeStep, printed map[string]bool) []*recipeFinder.RecipeNode {
 	var out []*recipeFinder.RecipeNode
 	for _, info := range infos {
 		single := make(recipeFinder.ProductToIngredients)
@@ -339,7 +346,7 @@ func infosToTrees(target string, infos []recipeFinder.RecipeStep, printed map[st
 				single[p[2]] = recipeFinder.RecipeStep{Combo: recipeFinder.IngredientCombo{A: p[0], B: p[1]}}
 			}
 		}
-		tree := recipeFinder.BuildTree(target, single)
+		tree := recipeFinder.BuildTree(ctx, target, single)
 		key, _ := json.Marshal(tree)
 		if !printed[string(key)] {
 			printed[string(key)] = true
@@ -349,6 +356,22 @@ func infosToTrees(target string, infos []recipeFinder.RecipeStep, printed map[st
 	return out
 }
 
+// hasRecipe reports whether a search result actually reaches target:
+// base elements always do, otherwise at least one tree needs children
+func hasRecipe(target string, trees ...*recipeFinder.RecipeNode) bool {
+	for _, b := range recipeFinder.BaseElements {
+		if b == target {
+			return true
+		}
+	}
+	for _, t := range trees {
+		if t != nil && len(t.Children) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // sortCatalogTiers sorts the tiers in catalog - "Starting" first, then numeric tiers in order
 func sortCatalogTiers(catalog *recipeFinder.Catalog) {
 	// "Starting" tier always comes first, then numeric tiers in order
@@ -0,0 +1,392 @@
+// backend/metrics.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// Prometheus metrics in the text exposition format, written by hand
+// (https://prometheus.io/docs/instrumenting/exposition_formats/).
+// Every metric name gets the metricsNamespace prefix.
+const metricsNamespace = "recipefinder"
+
+// Histogram buckets
+var (
+	latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+	nodeBuckets    = []float64{10, 100, 1e3, 1e4, 1e5, 1e6, 1e7}
+	resultBuckets  = []float64{0, 1, 2, 5, 10, 20, 50, 100}
+	scrapeBuckets  = []float64{1, 5, 10, 30, 60, 120, 300, 600}
+)
+
+// -----------------------------------------------------------------------------
+// Metrics
+// -----------------------------------------------------------------------------
+var (
+	httpRequests = newCounterVec("http_requests_total",
+		"HTTP requests by endpoint, algorithm and status code.", "endpoint", "algorithm", "code")
+	httpDuration = newHistogramVec("http_request_duration_seconds",
+		"HTTP request latency by endpoint and algorithm.", latencyBuckets, "endpoint", "algorithm")
+
+	searchNodes = newHistogramVec("search_nodes_visited",
+		"Nodes visited per search.", nodeBuckets, "algorithm", "multi")
+	searchResults = newHistogramVec("search_results",
+		"Recipe trees returned per search.", resultBuckets, "algorithm", "multi")
+	searchTruncated = newCounterVec("search_truncated_total",
+		"Searches that ran out of time and returned partial results.", "algorithm")
+	searchTimeouts = newCounterVec("search_timeouts_total",
+		"Searches that ran out of time without finding a recipe.", "algorithm")
+
+	findCacheRequests = newCounterVec("find_cache_requests_total",
+		"/api/find response cache lookups by result (hit or miss).", "result")
+
+	scrapes = newCounterVec("scrapes_total",
+		"Scrapes by trigger (startup, api, refresh) and outcome (success, failure).", "trigger", "outcome")
+	scrapeDuration = newHistogramVec("scrape_duration_seconds",
+		"Scrape duration by trigger.", scrapeBuckets, "trigger")
+	refreshRejected = newCounterVec("refresh_rejected_total",
+		"Scheduled refreshes whose scrape was discarded, by reason.", "reason")
+)
+
+func init() {
+	// Values owned by other packages are read when /metrics is scraped
+	register(&collectorFunc{
+		name: "search_goroutines_started_total", kind: "counter", labels: []string{"search"},
+		help: "Goroutines spawned by the parallel multi-path searches.",
+		collect: func(emit func(float64, ...string)) {
+			for search, c := range recipeFinder.GoroutineStats() {
+				emit(float64(c.Started), search)
+			}
+		},
+	})
+	register(&collectorFunc{
+		name: "search_goroutines_active", kind: "gauge", labels: []string{"search"},
+		help: "Goroutines of the parallel multi-path searches still running.",
+		collect: func(emit func(float64, ...string)) {
+			for search, c := range recipeFinder.GoroutineStats() {
+				emit(float64(c.Active), search)
+			}
+		},
+	})
+	register(&collectorFunc{
+		name: "goroutines", kind: "gauge",
+		help: "Goroutines of the whole process.",
+		collect: func(emit func(float64, ...string)) {
+			emit(float64(runtime.NumGoroutine()))
+		},
+	})
+}
+
+// -----------------------------------------------------------------------------
+// Recording helpers
+// -----------------------------------------------------------------------------
+
+// observeSearch records the outcome of one search
+func observeSearch(p findParams, nodes, trees int, timedOut bool) {
+	multi := strconv.FormatBool(p.Multi)
+	searchNodes.Observe(float64(nodes), p.Algorithm, multi)
+	searchResults.Observe(float64(trees), p.Algorithm, multi)
+	switch {
+	case timedOut && trees == 0:
+		searchTimeouts.Inc(p.Algorithm)
+	case timedOut:
+		searchTruncated.Inc(p.Algorithm)
+	}
+}
+
+// resultCount is the number of recipe trees in a search result
+func resultCount(target string, trees []*recipeFinder.RecipeNode) int {
+	if !hasRecipe(target, trees...) {
+		return 0
+	}
+	return len(trees)
+}
+
+// observeScrape records the duration and outcome of a scrape started at t0
+func observeScrape(trigger string, t0 time.Time, err error) {
+	scrapeDuration.Observe(time.Since(t0).Seconds(), trigger)
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	scrapes.Inc(trigger, outcome)
+}
+
+// instrument counts the requests of an endpoint and measures their latency.
+// Search endpoints are also labelled with the requested algorithm (not the
+// batch, which carries it in its body).
+func instrument(endpoint string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		t0 := time.Now()
+		h(rec, r)
+
+		algorithm := ""
+		if (strings.HasPrefix(endpoint, apiV1+"/find") || strings.HasPrefix(endpoint, apiLegacy+"/find")) &&
+			!strings.HasSuffix(endpoint, "/batch") {
+			algorithm = metricAlgorithm(r.URL.Query().Get("algorithm"))
+		}
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		httpRequests.Inc(endpoint, algorithm, strconv.Itoa(rec.status))
+		httpDuration.Observe(time.Since(t0).Seconds(), endpoint, algorithm)
+	}
+}
+
+// metricAlgorithm maps ?algorithm= to a label value, keeping the label set small
+func metricAlgorithm(v string) string {
+	switch v {
+	case "":
+		return "bfs"
+	case "bfs", "dfs", "bidirectional":
+		return v
+	}
+	return "invalid"
+}
+
+// statusRecorder remembers the status code written through it. It passes
+// Flush (SSE), Hijack (WebSocket) and Unwrap through to the real writer.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the real writer (deadlines)
+func (s *statusRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
+
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("hijacking is not supported")
+	}
+	s.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// handleMetrics serves /metrics in the Prometheus text format
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metricsMu.Lock()
+	all := append([]metric(nil), metrics...)
+	metricsMu.Unlock()
+	for _, m := range all {
+		m.write(w)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Registry
+// -----------------------------------------------------------------------------
+
+// metric is anything /metrics prints
+type metric interface {
+	write(w io.Writer)
+}
+
+var (
+	metricsMu sync.Mutex
+	metrics   []metric // in registration order
+)
+
+func register(m metric) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	metrics = append(metrics, m)
+}
+
+// series is the label values of one time series
+type series []string
+
+func (s series) key() string { return strings.Join(s, "\xff") }
+
+// labelSet formats names/values as {a="x",b="y"}, with extra appended last
+func labelSet(names []string, values []string, extra ...string) string {
+	var parts []string
+	for i, n := range names {
+		parts = append(parts, n+`="`+escapeLabel(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		parts = append(parts, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s_%s %s\n# TYPE %s_%s %s\n", metricsNamespace, name, help, metricsNamespace, name, kind)
+}
+
+// sortedKeys returns the keys of a series map in a stable order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ---------- counters ----------
+
+// counterVec is a counter with labels
+type counterVec struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	values map[string]float64
+	series map[string]series
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	c := &counterVec{name: name, help: help, labels: labels,
+		values: make(map[string]float64), series: make(map[string]series)}
+	register(c)
+	return c
+}
+
+// Inc adds 1 to the series with the given label values
+func (c *counterVec) Inc(values ...string) {
+	key := series(values).key()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key]++
+	c.series[key] = values
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s_%s%s %s\n", metricsNamespace, c.name, labelSet(c.labels, c.series[key]), formatValue(c.values[key]))
+	}
+}
+
+// ---------- histograms ----------
+
+// histogram is one series of a histogramVec; counts are per bucket, not cumulative
+type histogram struct {
+	values series
+	counts []uint64 // one per bucket, plus +Inf
+	sum    float64
+	count  uint64
+}
+
+// histogramVec is a histogram with labels
+type histogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64 // upper bounds, ascending
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	h := &histogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogram)}
+	register(h)
+	return h
+}
+
+// Observe adds v to the series with the given label values
+func (h *histogramVec) Observe(v float64, values ...string) {
+	key := series(values).key()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{values: values, counts: make([]uint64, len(h.buckets)+1)}
+		h.series[key] = s
+	}
+	s.counts[sort.SearchFloat64s(h.buckets, v)]++ // first bucket with v <= bound
+	s.sum += v
+	s.count++
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	writeHeader(w, h.name, h.help, "histogram")
+	name := metricsNamespace + "_" + h.name
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, labelSet(h.labels, s.values, "le", formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, labelSet(h.labels, s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, labelSet(h.labels, s.values), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, labelSet(h.labels, s.values), s.count)
+	}
+}
+
+// ---------- values read on demand ----------
+
+// collectorFunc reports values owned elsewhere; collect calls emit once per series
+type collectorFunc struct {
+	name, help, kind string
+	labels           []string
+	collect          func(emit func(v float64, values ...string))
+}
+
+func (c *collectorFunc) write(w io.Writer) {
+	type sample struct {
+		values series
+		v      float64
+	}
+	samples := make(map[string]sample)
+	c.collect(func(v float64, values ...string) {
+		samples[series(values).key()] = sample{values, v}
+	})
+
+	writeHeader(w, c.name, c.help, c.kind)
+	for _, key := range sortedKeys(samples) {
+		s := samples[key]
+		fmt.Fprintf(w, "%s_%s%s %s\n", metricsNamespace, c.name, labelSet(c.labels, s.values), formatValue(s.v))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 
@@ -18,7 +19,8 @@ type PathExploration struct {
 }
 
 // Multi-recipe BFS that works in reverse (target → base)
-func ReversedMultiPathBFS(targetName string, graph IndexedGraph, maxPaths int) ([]ProductToIngredients, []SearchStep, int) {
+// Stops early when ctx is done and returns the complete paths found so far
+func ReversedMultiPathBFS(ctx context.Context, targetName string, graph IndexedGraph, maxPaths int) ([]ProductToIngredients, []SearchStep, int) {
     targetID := graph.NameToID[targetName]
     
     // Track complete paths (each is a separate recipe tree)
@@ -31,7 +33,7 @@ func ReversedMultiPathBFS(targetName string, graph IndexedGraph, maxPaths int) (
     
     // We'll need a reverse graph (product → ingredient combinations)
     // This gives us all ways to create an element
-    reverseGraph := buildReverseGraph(graph)
+    reverseGraph := multiPathReverseGraph(graph)
     
     // Each path is a BFS exploration from target to base
     // We'll track multiple separate path explorations
@@ -67,7 +69,7 @@ func ReversedMultiPathBFS(targetName string, graph IndexedGraph, maxPaths int) (
     activeExplorations := []PathExploration{initialExploration}
     
     // We'll keep exploring paths until we have enough or run out of options
-    for len(completePaths) < maxPaths && len(activeExplorations) > 0 {
+    for len(completePaths) < maxPaths && len(activeExplorations) > 0 && ctx.Err() == nil {
         // Take the first active exploration
         currentExploration := activeExplorations[0]
         activeExplorations = activeExplorations[1:]
@@ -242,10 +244,29 @@ func buildReverseGraph(graph IndexedGraph) map[int][]struct{ InputA, InputB int
             })
         }
     }
+
+    // Canonical recipe order (graph.Edges is a map, so insertion order varies)
+    for _, recs := range reverse {
+        sort.Slice(recs, func(i, j int) bool {
+            if recs[i].InputA != recs[j].InputA {
+                return recs[i].InputA < recs[j].InputA
+            }
+            return recs[i].InputB < recs[j].InputB
+        })
+    }
     
     return reverse
 }
 
+// multiPathReverseGraph returns the reverse graph the multi-path BFS searches
+// share, built once per catalog by BuildReverseIndex
+func multiPathReverseGraph(graph IndexedGraph) map[int][]struct{ InputA, InputB int } {
+    if graph.memo != nil && graph.memo.multiPathReverse != nil {
+        return graph.memo.multiPathReverse
+    }
+    return buildReverseGraph(graph)
+}
+
 // Clone a path exploration
 func cloneExploration(original PathExploration) PathExploration {
     clone := PathExploration{
@@ -325,132 +346,210 @@ func shallowCloneExploration(orig PathExploration) PathExploration {
     }
 }
 
-func ReversedMultiPathBFSParallel(targetName string, graph IndexedGraph, maxPaths int) ([]ProductToIngredients, []SearchStep, int) {
+// MaxParallelSteps caps the snapshots ReversedMultiPathBFSParallel records
+const MaxParallelSteps = 1000
+
+// ReversedMultiPathBFSParallel is ReversedMultiPathBFS with each batch of
+// explorations processed concurrently. Stops early when ctx is done.
+// The returned steps are snapshots of the combined frontier of all explorations.
+func ReversedMultiPathBFSParallel(ctx context.Context, targetName string, graph IndexedGraph, maxPaths int) ([]ProductToIngredients, []SearchStep, int) {
+    steps := NewStepTracer(graph, graph.NameToID[targetName], MaxParallelSteps)
+    paths, nodes := ReversedMultiPathBFSParallelStream(ctx, targetName, graph, maxPaths, MultiPathOptions{}, nil, steps)
+    return paths, steps.Steps(), nodes
+}
+
+// ReversedMultiPathBFSParallelStream is ReversedMultiPathBFSParallel that also
+// hands every new complete path to onPath the moment it is found (used for
+// streaming responses) and reports its steps to t. Calls to onPath and t are
+// serialized; nil callbacks are ignored.
+//
+// Each batch of explorations is spread over a pool of opts.Workers
+// goroutines. Workers write their branches into per-exploration slots and
+// send complete paths to a single collector, so no result is shared between
+// goroutines; the collector cancels the remaining work once maxPaths unique
+// paths are in.
+// With opts.Deterministic complete paths are collected in batch order once
+// the whole batch is done, so paths only reach onPath at the end of each batch.
+func ReversedMultiPathBFSParallelStream(ctx context.Context, targetName string, graph IndexedGraph, maxPaths int, opts MultiPathOptions, onPath func(ProductToIngredients), t Tracer) ([]ProductToIngredients, int) {
     targetID := graph.NameToID[targetName]
+    t = syncTracer(t)
+
+    searchCtx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
     var (
-        completePaths  []ProductToIngredients
-        pathHashes     = make(map[string]bool)
-        allSearchSteps []SearchStep
-        totalNodes     int
-        mu             sync.Mutex
-        wg             sync.WaitGroup
+        completePaths []ProductToIngredients
+        pathHashes    = make(map[string]bool)
+        collected     int64 // len(completePaths), readable from any goroutine
+        totalNodes    int64
     )
 
-    reverseGraph := buildReverseGraph(graph)
+    // collect keeps a complete path unless it's a duplicate. It only ever
+    // runs on one goroutine at a time: the collector below, or the caller's
+    // goroutine in deterministic mode.
+    collect := func(path ProductToIngredients) {
+        if int(atomic.LoadInt64(&collected)) >= maxPaths {
+            return
+        }
+        hash := createPathHash(path)
+        if pathHashes[hash] {
+            return
+        }
+        pathHashes[hash] = true
+        completePaths = append(completePaths, path)
+        t.OnSolution(targetID)
+        if onPath != nil {
+            onPath(path)
+        }
+        if atomic.AddInt64(&collected, 1) == int64(maxPaths) {
+            cancel() // enough paths, stop every worker
+        }
+    }
+
+    // Paths found by the workers; drained by a single collector goroutine
+    paths := make(chan ProductToIngredients, maxPaths)
+    collectorDone := make(chan struct{})
+    parallelBFSGoroutines.start()
+    go func() {
+        defer parallelBFSGoroutines.done()
+        defer close(collectorDone)
+        for path := range paths {
+            collect(path)
+        }
+    }()
+
+    reverseGraph := multiPathReverseGraph(graph)
+
+    // choose records recipe r for the element and queues its missing ingredients
+    choose := func(pe *PathExploration, curID int, r struct{ InputA, InputB int }) {
+        name := graph.IDToName[curID]
+        pe.Path[name] = RecipeStep{Combo: IngredientCombo{
+            A: graph.IDToName[r.InputA],
+            B: graph.IDToName[r.InputB],
+        }}
+        delete(pe.IncompletePath, name)
+        t.OnDiscover(curID, r.InputA, r.InputB)
+        for _, id := range []int{r.InputA, r.InputB} {
+            queued := pe.Queue.Len()
+            addIngredientToExploration(id, graph, pe)
+            if pe.Queue.Len() > queued {
+                t.OnEnqueue(id)
+            }
+        }
+    }
+
+    // explore runs one exploration until it is complete or its queue is empty.
+    // Alternative recipes become new explorations for the next batch.
+    explore := func(pe PathExploration) (branches []PathExploration, complete ProductToIngredients) {
+        for pe.Queue.Len() > 0 {
+            if searchCtx.Err() != nil {
+                return branches, nil // abandon this exploration
+            }
+            front := pe.Queue.Front()
+            curID := pe.Queue.Remove(front).(int)
+            name := graph.IDToName[curID]
+            pe.NodeCount++
+            atomic.AddInt64(&totalNodes, 1)
+            t.OnVisit(curID)
+
+            if isBaseElement(name) {
+                delete(pe.IncompletePath, name)
+                continue
+            }
+
+            recs := reverseGraph[curID]
+            if len(recs) > 1 {
+                // do first recipe in this exploration
+                choose(&pe, curID, recs[0])
+
+                for j := 1; j < len(recs); j++ {
+                    branch := shallowCloneExploration(pe)
+                    choose(&branch, curID, recs[j])
+                    branches = append(branches, branch)
+                }
+            } else if len(recs) == 1 {
+                choose(&pe, curID, recs[0])
+            }
+
+            if len(pe.IncompletePath) == 0 {
+                return branches, pe.Path
+            }
+        }
+        return branches, nil
+    }
+
+    // ---------- worker pool ----------
+    type job struct {
+        pe       PathExploration
+        branches *[]PathExploration      // this exploration's slot in the batch
+        found    *ProductToIngredients   // set in deterministic mode only
+    }
+    jobs := make(chan job)
+    var batch sync.WaitGroup
+    for w := 0; w < opts.workers(); w++ {
+        parallelBFSGoroutines.start()
+        go func() {
+            defer parallelBFSGoroutines.done()
+            for j := range jobs {
+                branches, complete := explore(j.pe)
+                *j.branches = branches
+                if complete != nil {
+                    if opts.Deterministic {
+                        *j.found = complete
+                    } else {
+                        paths <- complete
+                    }
+                }
+                batch.Done()
+            }
+        }()
+    }
 
     initial := PathExploration{
         Path:           make(map[string]RecipeStep),
         IncompletePath: map[string]bool{targetName: true},
         Queue:          list.New(),
-        SearchSteps:    []SearchStep{{ // keep just the very first step
-            CurrentID:  -1, CurrentName: "", QueueIDs: []int{targetID}, QueueNames: []string{targetName},
-            SeenIDs: nil, SeenNames: nil, DiscoveredEdges: nil, DiscoveredNames: nil,
-            StepNumber: 0, FoundTarget: false,
-        }},
-        NodeCount: 0,
+        NodeCount:      0,
     }
     initial.Queue.PushBack(targetID)
+    t.OnEnqueue(targetID)
 
     active := []PathExploration{initial}
-    limiter := make(chan struct{}, 4) // max 4 goroutines at once
-
-    for len(completePaths) < maxPaths && len(active) > 0 {
-        nextBatch := make([]PathExploration, 0, len(active))
-
-        for _, pe := range active {
-            wg.Add(1)
-            limiter <- struct{}{}
-
-            go func(pe PathExploration) {
-                defer wg.Done()
-                defer func() { <-limiter }()
-
-                const maxSteps = 1000
-                for pe.Queue.Len() > 0 {
-                    front := pe.Queue.Front()
-                    curID := pe.Queue.Remove(front).(int)
-                    name := graph.IDToName[curID]
-                    pe.NodeCount++
-                    totalNodes++
-
-                    // only record up to maxSteps
-                    if len(pe.SearchSteps) < maxSteps {
-                        pe.SearchSteps = append(pe.SearchSteps, SearchStep{
-                            CurrentID:   curID,
-                            CurrentName: name,
-                            QueueIDs:    queueToSlice(pe.Queue),
-                            QueueNames:  queueToNameSlice(pe.Queue, graph),
-                            StepNumber:  pe.NodeCount,
-                            FoundTarget: false,
-                        })
-                    }
+    for int(atomic.LoadInt64(&collected)) < maxPaths && len(active) > 0 && searchCtx.Err() == nil {
+        branches := make([][]PathExploration, len(active))
+        found := make([]ProductToIngredients, len(active))
 
-                    if isBaseElement(name) {
-                        delete(pe.IncompletePath, name)
-                        continue
-                    }
-
-                    recs := reverseGraph[curID]
-                    if len(recs) > 1 {
-                        // do first recipe in this goroutine
-                        first := recs[0]
-                        pe.Path[name] = RecipeStep{Combo: IngredientCombo{
-                            A: graph.IDToName[first.InputA],
-                            B: graph.IDToName[first.InputB],
-                        }}
-                        delete(pe.IncompletePath, name)
-                        addIngredientToExploration(first.InputA, graph, &pe)
-                        addIngredientToExploration(first.InputB, graph, &pe)
-
-                        for j := 1; j < len(recs); j++ {
-                            r := recs[j]
-                            branch := shallowCloneExploration(pe)
-                            branch.Path[name] = RecipeStep{Combo: IngredientCombo{
-                                A: graph.IDToName[r.InputA],
-                                B: graph.IDToName[r.InputB],
-                            }}
-                            delete(branch.IncompletePath, name)
-                            addIngredientToExploration(r.InputA, graph, &branch)
-                            addIngredientToExploration(r.InputB, graph, &branch)
-
-                            mu.Lock()
-                            nextBatch = append(nextBatch, branch)
-                            mu.Unlock()
-                        }
-                    } else if len(recs) == 1 {
-                        r := recs[0]
-                        pe.Path[name] = RecipeStep{Combo: IngredientCombo{
-                            A: graph.IDToName[r.InputA],
-                            B: graph.IDToName[r.InputB],
-                        }}
-                        delete(pe.IncompletePath, name)
-                        addIngredientToExploration(r.InputA, graph, &pe)
-                        addIngredientToExploration(r.InputB, graph, &pe)
-                    }
+        for i, pe := range active {
+            if searchCtx.Err() != nil {
+                break
+            }
+            batch.Add(1)
+            jobs <- job{pe: pe, branches: &branches[i], found: &found[i]}
+        }
+        batch.Wait()
 
-                    if len(pe.IncompletePath) == 0 {
-                        hash := createPathHash(pe.Path)
-                        mu.Lock()
-                        if !pathHashes[hash] && len(completePaths) < maxPaths {
-                            pathHashes[hash] = true
-                            completePaths = append(completePaths, pe.Path)
-                            allSearchSteps = append(allSearchSteps, pe.SearchSteps...)
-                        }
-                        mu.Unlock()
-                        return
-                    }
+        if opts.Deterministic {
+            for _, path := range found {
+                if path != nil {
+                    collect(path)
                 }
-            }(pe)
+            }
         }
 
-        wg.Wait()
+        nextBatch := make([]PathExploration, 0, len(active))
+        for _, b := range branches {
+            nextBatch = append(nextBatch, b...)
+        }
         active = nextBatch
         if len(active) > maxPaths*3 {
             active = active[:maxPaths*3]
         }
     }
 
-    return completePaths, allSearchSteps, totalNodes
+    close(jobs)
+    close(paths)
+    <-collectorDone
+    return completePaths, int(atomic.LoadInt64(&totalNodes))
 }
 
 // Helper function to process a single exploration
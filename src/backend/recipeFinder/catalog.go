@@ -0,0 +1,179 @@
+// backend/recipeFinder/catalog.go
+// Catalog validation, diffing and per-element metadata lookups
+
+package recipeFinder
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CatalogDiff summarises what changed between two catalogs.
+// Recipes are compared per element, ignoring ingredient order.
+type CatalogDiff struct {
+	AddedElements   []string `json:"added_elements"`   // Elements only in the new catalog
+	RemovedElements []string `json:"removed_elements"` // Elements only in the old catalog
+	AddedRecipes    int      `json:"added_recipes"`    // Recipes only in the new catalog
+	RemovedRecipes  int      `json:"removed_recipes"`  // Recipes only in the old catalog
+	OldElements     int      `json:"old_elements"`     // Element count of the old catalog
+	OldRecipes      int      `json:"old_recipes"`      // Recipe count of the old catalog
+}
+
+// RemovedElementRatio returns the fraction of old elements missing from the new catalog
+func (d CatalogDiff) RemovedElementRatio() float64 {
+	if d.OldElements == 0 {
+		return 0
+	}
+	return float64(len(d.RemovedElements)) / float64(d.OldElements)
+}
+
+// RemovedRecipeRatio returns the fraction of old recipes missing from the new catalog
+func (d CatalogDiff) RemovedRecipeRatio() float64 {
+	if d.OldRecipes == 0 {
+		return 0
+	}
+	return float64(d.RemovedRecipes) / float64(d.OldRecipes)
+}
+
+// CountElements returns the number of elements and recipes in a catalog
+func CountElements(cat Catalog) (elements, recipes int) {
+	for _, tier := range cat.Tiers {
+		elements += len(tier.Elements)
+		for _, el := range tier.Elements {
+			recipes += len(el.Recipes)
+		}
+	}
+	return elements, recipes
+}
+
+// ValidateCatalog checks that a catalog is structurally usable for searching:
+// it must contain elements, include every base element, have no duplicate
+// names and only contain two-ingredient recipes.
+// Ingredients outside the catalog (e.g. from the skipped "Special" tier) are allowed.
+func ValidateCatalog(cat Catalog) error {
+	names := make(map[string]bool)
+	for _, tier := range cat.Tiers {
+		for _, el := range tier.Elements {
+			if el.Name == "" {
+				return fmt.Errorf("tier %q contains an element without a name", tier.Name)
+			}
+			if names[el.Name] {
+				return fmt.Errorf("element %q appears more than once", el.Name)
+			}
+			names[el.Name] = true
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("catalog contains no elements")
+	}
+
+	for _, base := range BaseElements {
+		if !names[base] {
+			return fmt.Errorf("base element %q is missing", base)
+		}
+	}
+
+	for _, tier := range cat.Tiers {
+		for _, el := range tier.Elements {
+			for _, rec := range el.Recipes {
+				if len(rec) != 2 {
+					return fmt.Errorf("recipe for %q has %d ingredients", el.Name, len(rec))
+				}
+				for _, ingredient := range rec {
+					if ingredient == "" {
+						return fmt.Errorf("recipe for %q has an empty ingredient", el.Name)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// DiffCatalogs compares an old catalog against a new one
+func DiffCatalogs(old, new Catalog) CatalogDiff {
+	oldRecipes := recipeSets(old)
+	newRecipes := recipeSets(new)
+
+	var diff CatalogDiff
+	diff.OldElements, diff.OldRecipes = CountElements(old)
+
+	for name, recs := range newRecipes {
+		prev, existed := oldRecipes[name]
+		if !existed {
+			diff.AddedElements = append(diff.AddedElements, name)
+		}
+		for key := range recs {
+			if !prev[key] {
+				diff.AddedRecipes++
+			}
+		}
+	}
+	for name, recs := range oldRecipes {
+		next, exists := newRecipes[name]
+		if !exists {
+			diff.RemovedElements = append(diff.RemovedElements, name)
+		}
+		for key := range recs {
+			if !next[key] {
+				diff.RemovedRecipes++
+			}
+		}
+	}
+
+	sort.Strings(diff.AddedElements)
+	sort.Strings(diff.RemovedElements)
+	return diff
+}
+
+// recipeSets maps each element name to the set of its order-independent recipe keys
+func recipeSets(cat Catalog) map[string]map[string]bool {
+	sets := make(map[string]map[string]bool)
+	for _, tier := range cat.Tiers {
+		for _, el := range tier.Elements {
+			set := make(map[string]bool, len(el.Recipes))
+			for _, rec := range el.Recipes {
+				if len(rec) != 2 {
+					continue
+				}
+				a, b := rec[0], rec[1]
+				if b < a {
+					a, b = b, a
+				}
+				set[a+"+"+b] = true
+			}
+			sets[el.Name] = set
+		}
+	}
+	return sets
+}
+
+// ElementMeta is the catalog information about a single element
+type ElementMeta struct {
+	Name      string     `json:"name"`
+	Tier      string     `json:"tier"`       // Tier name as on the wiki ("Starting", "1", ...)
+	TierLevel int        `json:"tier_level"` // Numeric tier level used by the searches
+	SVGPath   string     `json:"svg_path"`   // Relative path of the local SVG icon
+	Recipes   [][]string `json:"recipes"`    // Ingredient pairs that make this element
+}
+
+// Global element metadata index, built alongside GlobalIndexedGraph
+var GlobalElementIndex map[string]ElementMeta
+
+// BuildElementIndex maps every catalog element name to its metadata.
+// InitElementTiers must have been called for the same catalog first.
+func BuildElementIndex(cat Catalog) map[string]ElementMeta {
+	idx := make(map[string]ElementMeta)
+	for _, tier := range cat.Tiers {
+		for _, el := range tier.Elements {
+			idx[el.Name] = ElementMeta{
+				Name:      el.Name,
+				Tier:      tier.Name,
+				TierLevel: getElementTier(el.Name),
+				SVGPath:   el.LocalSVGPath,
+				Recipes:   el.Recipes,
+			}
+		}
+	}
+	return idx
+}
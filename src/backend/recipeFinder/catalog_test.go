@@ -0,0 +1,86 @@
+package recipeFinder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateCatalog(t *testing.T) {
+	if err := ValidateCatalog(syntheticCatalog()); err != nil {
+		t.Fatalf("synthetic catalog: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		change func(cat *Catalog)
+		want   string
+	}{
+		{"empty", func(cat *Catalog) { cat.Tiers = nil }, "no elements"},
+		{"missing base element", func(cat *Catalog) {
+			cat.Tiers[0].Elements = cat.Tiers[0].Elements[1:]
+		}, `base element "Air"`},
+		{"duplicate", func(cat *Catalog) {
+			cat.Tiers[2].Elements = append(cat.Tiers[2].Elements, Element{Name: "Mud"})
+		}, `"Mud" appears more than once`},
+		{"unnamed element", func(cat *Catalog) {
+			cat.Tiers[1].Elements[0].Name = ""
+		}, "without a name"},
+		{"three ingredients", func(cat *Catalog) {
+			cat.Tiers[1].Elements[0].Recipes[0] = []string{"Water", "Earth", "Fire"}
+		}, "3 ingredients"},
+		{"empty ingredient", func(cat *Catalog) {
+			cat.Tiers[1].Elements[0].Recipes[0] = []string{"Water", ""}
+		}, "empty ingredient"},
+	}
+	for _, c := range cases {
+		cat := syntheticCatalog()
+		c.change(&cat)
+		if err := ValidateCatalog(cat); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: error %v, want one containing %q", c.name, err, c.want)
+		}
+	}
+
+	// Ingredients from outside the catalog (the skipped "Special" tier) are fine
+	cat := syntheticCatalog()
+	cat.Tiers[1].Elements[0].Recipes = append(cat.Tiers[1].Elements[0].Recipes, []string{"Time", "Water"})
+	if err := ValidateCatalog(cat); err != nil {
+		t.Errorf("outside ingredient: %v", err)
+	}
+}
+
+func TestDiffCatalogs(t *testing.T) {
+	old := syntheticCatalog()
+	oldElements, oldRecipes := CountElements(old)
+
+	next := syntheticCatalog()
+	// Chicken (2 recipes) goes, Mud's recipe is reordered and gets a second one, Dust is new
+	next.Tiers[5].Elements = nil
+	next.Tiers[1].Elements[0].Recipes = [][]string{{"Earth", "Water"}, {"Mud", "Water"}}
+	next.Tiers = append(next.Tiers, Tier{Name: "6", Elements: []Element{{Name: "Dust", Recipes: [][]string{{"Earth", "Air"}}}}})
+
+	diff := DiffCatalogs(old, next)
+	want := CatalogDiff{
+		AddedElements:   []string{"Dust"},
+		RemovedElements: []string{"Chicken"},
+		AddedRecipes:    2, // Mud+Water, Dust's
+		RemovedRecipes:  2, // Chicken's; Mud's reordered recipe is unchanged
+		OldElements:     oldElements,
+		OldRecipes:      oldRecipes,
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("diff %+v, want %+v", diff, want)
+	}
+	if got := diff.RemovedElementRatio(); got != 1/float64(oldElements) {
+		t.Errorf("removed element ratio %v", got)
+	}
+	if got := diff.RemovedRecipeRatio(); got != 2/float64(oldRecipes) {
+		t.Errorf("removed recipe ratio %v", got)
+	}
+
+	// Nothing to remove from an empty catalog
+	if empty := DiffCatalogs(Catalog{}, old); empty.RemovedElementRatio() != 0 || empty.RemovedRecipeRatio() != 0 ||
+		len(empty.AddedElements) != oldElements {
+		t.Errorf("from empty: %+v", empty)
+	}
+}
@@ -0,0 +1,121 @@
+package recipeFinder
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// syntheticCatalog is a small catalog with alternative recipes for most
+// elements, enough to give the multi-path searches several roots and
+// branches to run in parallel
+func syntheticCatalog() Catalog {
+	el := func(name string, recipes ...[]string) Element {
+		return Element{Name: name, Recipes: recipes}
+	}
+	r := func(a, b string) []string { return []string{a, b} }
+
+	return Catalog{Tiers: []Tier{
+		{Name: "Starting", Elements: []Element{el("Air"), el("Earth"), el("Fire"), el("Water")}},
+		{Name: "1", Elements: []Element{
+			el("Mud", r("Water", "Earth")),
+			el("Steam", r("Water", "Fire")),
+			el("Lava", r("Earth", "Fire")),
+			el("Pressure", r("Air", "Air")),
+			el("Energy", r("Fire", "Fire")),
+		}},
+		{Name: "2", Elements: []Element{
+			el("Stone", r("Lava", "Air"), r("Earth", "Pressure")),
+			el("Cloud", r("Steam", "Air"), r("Water", "Pressure")),
+			el("Brick", r("Mud", "Fire"), r("Mud", "Stone")),
+		}},
+		{Name: "3", Elements: []Element{
+			el("Wall", r("Brick", "Brick"), r("Stone", "Stone")),
+			el("Rain", r("Cloud", "Water"), r("Cloud", "Cloud")),
+			el("Sand", r("Stone", "Air"), r("Stone", "Water")),
+			el("Life", r("Energy", "Mud"), r("Rain", "Earth")),
+		}},
+		{Name: "4", Elements: []Element{
+			el("House", r("Wall", "Wall"), r("Wall", "Brick")),
+			el("Glass", r("Sand", "Fire"), r("Sand", "Energy")),
+			el("Egg", r("Life", "Stone"), r("Life", "Sand")),
+		}},
+		{Name: "5", Elements: []Element{
+			el("Chicken", r("Egg", "Life"), r("Egg", "Wall")),
+		}},
+	}}
+}
+
+// loadSyntheticCatalog installs the synthetic catalog the way the server
+// activates a snapshot and returns its graph
+func loadSyntheticCatalog() IndexedGraph {
+	cat := syntheticCatalog()
+	InitElementTiers(cat)
+	g := BuildIndexedGraph(cat)
+	BuildReverseIndex(g)
+	return g
+}
+
+// searchResults is everything one round of searches returns for a target
+type searchResults struct {
+	bfs       []ProductToIngredients
+	dfs       []RecipeStep
+	singleDFS ProductToIngredients
+}
+
+func runSearches(g IndexedGraph, target string) searchResults {
+	ctx := context.Background()
+	opts := MultiPathOptions{Deterministic: true, Workers: 4}
+
+	var res searchResults
+	res.bfs, _ = ReversedMultiPathBFSParallelStream(ctx, target, g, 4, opts, nil, nil)
+	res.dfs, _ = RangeDFSPathsStream(ctx, target, 4, g, opts, nil, nil)
+	res.singleDFS, _ = DFSBuildTargetToBase(ctx, target, g)
+	return res
+}
+
+// TestConcurrentSearchesAreDeterministic runs the parallel multi-path BFS and
+// DFS for several targets from many goroutines at once, as concurrent
+// requests do. In deterministic mode every run must return what a lone
+// sequential run returns. The single-path DFS runs alongside for the race
+// detector (its memo is per search); it has no deterministic mode, since it
+// walks the graph's edge maps, so it only has to find a recipe. Run with -race.
+func TestConcurrentSearchesAreDeterministic(t *testing.T) {
+	g := loadSyntheticCatalog()
+	targets := []string{"Brick", "House", "Glass", "Rain", "Egg", "Chicken"}
+
+	want := make(map[string]searchResults)
+	for _, target := range targets {
+		res := runSearches(g, target)
+		if len(res.bfs) == 0 || len(res.dfs) == 0 || len(res.singleDFS) == 0 {
+			t.Fatalf("%s: expected recipes from every search, got %d BFS, %d DFS, %d single DFS steps",
+				target, len(res.bfs), len(res.dfs), len(res.singleDFS))
+		}
+		want[target] = res
+	}
+
+	const goroutines, rounds = 8, 5
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				// Each goroutine walks the targets in a different order
+				target := targets[(i+round)%len(targets)]
+				got := runSearches(g, target)
+				if !reflect.DeepEqual(got.bfs, want[target].bfs) {
+					t.Errorf("%s: multi-path BFS differs between runs:\n got %v\nwant %v", target, got.bfs, want[target].bfs)
+				}
+				if !reflect.DeepEqual(got.dfs, want[target].dfs) {
+					t.Errorf("%s: multi-path DFS differs between runs:\n got %v\nwant %v", target, got.dfs, want[target].dfs)
+				}
+				if _, ok := got.singleDFS[target]; !ok {
+					t.Errorf("%s: single-path DFS found no recipe: %v", target, got.singleDFS)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
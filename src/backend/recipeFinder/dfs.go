@@ -2,7 +2,6 @@ package recipeFinder
 
 import (
 	"context"
-	"runtime"
 	"sort"
 	"sync"
 	"sync/atomic"
@@ -19,7 +18,12 @@ type pair struct{ a, b int } // Represents an ingredient pair (a,b)
 type revIndex map[int][]pair // Maps product ID to all ingredient pairs
 
 
-// BuildReverseIndex creates a reverse mapping from products to their ingredient pairs.
+// BuildReverseIndex creates a reverse mapping from products to their ingredient pairs,
+// together with the other memos every search on the catalog shares: which
+// elements can be made from the base elements at all, and the multi-path BFS
+// reverse graph. They are stored with g (made by BuildIndexedGraph), so every
+// copy of g sees them.
+// It is built once per catalog, before any search runs; the searches only read it.
 func BuildReverseIndex(g IndexedGraph) {
 	idx := make(revIndex)
 
@@ -32,18 +36,57 @@ func BuildReverseIndex(g IndexedGraph) {
 		}
 	}
 
+	// Elements that can be made: the base elements, then every product with a
+	// pair whose ingredients can both be made, until nothing changes
+	canMake := make(map[int]bool, len(g.IDToName))
+	for _, name := range BaseElements {
+		if id, ok := g.NameToID[name]; ok {
+			canMake[id] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for product, list := range idx {
+			if canMake[product] {
+				continue
+			}
+			for _, p := range list {
+				if canMake[p.a] && canMake[p.b] {
+					canMake[product] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
 	// Second pass: sort pairs by total tier (complexity) of ingredients
-	// This prioritizes simpler ingredients during search
+	// This prioritizes simpler ingredients during search; ties are broken by
+	// ID so the order doesn't depend on map iteration
 	for _, list := range idx {
 		sort.Slice(list, func(i, j int) bool {
-			ti := getElementTier(g.IDToName[list[i].a]) +
-				getElementTier(g.IDToName[list[i].b])
-			tj := getElementTier(g.IDToName[list[j].a]) +
-				getElementTier(g.IDToName[list[j].b])
-			return ti < tj
+			ti := g.tier(g.IDToName[list[i].a]) +
+				g.tier(g.IDToName[list[i].b])
+			tj := g.tier(g.IDToName[list[j].a]) +
+				g.tier(g.IDToName[list[j].b])
+			if ti != tj {
+				return ti < tj
+			}
+			if list[i].a != list[j].a {
+				return list[i].a < list[j].a
+			}
+			return list[i].b < list[j].b
 		})
 	}
-	revIdx = idx // Set the global variable
+	g.memo.revIdx = idx // Shared by every copy of g
+	g.memo.reachable = canMake
+	g.memo.multiPathReverse = buildReverseGraph(g)
+}
+
+// unreachable reports whether no recipe chain leads from the base elements to
+// id (false before BuildReverseIndex has run)
+func (g IndexedGraph) unreachable(id int) bool {
+	return g.memo != nil && g.memo.reachable != nil && !g.memo.reachable[id]
 }
 
 /*
@@ -53,8 +96,6 @@ Single-path DFS (recursive)
 This algorithm finds a single path from a target element to base elements using
 depth-first search with caching and pruning optimizations.
 */
-var canReachBaseCache map[int]bool // Memoization cache: elementID → can reach base?
-
 // findPathToBaseCnt is a recursive DFS function that finds a path from an element to base elements.
 // Parameters:
 //   - id: Current element ID being processed
@@ -63,43 +104,65 @@ var canReachBaseCache map[int]bool // Memoization cache: elementID → can reach
 //   - g: The indexed graph containing all element relationships
 //   - recipes: Output map to store the found recipe steps
 //   - visit: Map to track visited elements (prevents cycles)
+//   - canReach: Memoization cache of this search: elementID → can reach base?
 //   - counter: Pointer to count nodes visited (for statistics)
+//   - t: Tracer notified of every step (never nil)
 //
 // Returns:
 //   - bool: True if a path to base elements was found, false otherwise
 func findPathToBaseCnt(
+	ctx context.Context,
 	id, depth, maxDepth int,
 	g IndexedGraph,
 	recipes ProductToIngredients,
 	visit map[int]bool,
+	canReach map[int]bool,
 	counter *int,
+	t Tracer,
 ) bool {
 	// Stop if we've gone too deep (prevents stack overflow)
 	if depth > maxDepth {
 		return false
 	}
 
+	// Stop if the search was cancelled (nothing is cached for this node)
+	if ctx.Err() != nil {
+		return false
+	}
+
 	// Check the cache for previous results (memoization)
-	if res, ok := canReachBaseCache[id]; ok {
+	if res, ok := canReach[id]; ok {
+		t.OnPrune(EventCacheHit, id, 0, 0)
 		return res
 	}
 
+	// Elements that can't be made fail on every path, in every search
+	if g.unreachable(id) {
+		t.OnPrune(EventCacheHit, id, 0, 0)
+		return false
+	}
+
 	// Detect cycles in the current path
 	if visit[id] {
-		canReachBaseCache[id] = false
+		t.OnPrune(EventCycle, id, 0, 0)
+		canReach[id] = false
 		return false
 	}
 
 	// Mark as visited temporarily for this path
 	visit[id] = true
-	defer func() { visit[id] = false }() // Clean up before returning
-	*counter++                           // Count this node as visited
+	t.OnEnqueue(id)
+	defer func() {
+		visit[id] = false // Clean up before returning
+		t.OnVisit(id)
+	}()
+	*counter++ // Count this node as visited
 
 	// Check if current element is a base element (success case)
 	name := g.IDToName[id]
 	for _, b := range BaseElements {
 		if name == b {
-			canReachBaseCache[id] = true
+			canReach[id] = true
 			return true
 		}
 	}
@@ -109,8 +172,8 @@ func findPathToBaseCnt(
 
 	// Sort ingredients by total tier (simpler ingredients first)
 	sort.Slice(ing, func(i, j int) bool {
-		ti := getElementTier(g.IDToName[ing[i][0]]) + getElementTier(g.IDToName[ing[i][1]])
-		tj := getElementTier(g.IDToName[ing[j][0]]) + getElementTier(g.IDToName[ing[j][1]])
+		ti := g.tier(g.IDToName[ing[i][0]]) + g.tier(g.IDToName[ing[i][1]])
+		tj := g.tier(g.IDToName[ing[j][0]]) + g.tier(g.IDToName[ing[j][1]])
 		return ti < tj
 	})
 
@@ -119,8 +182,8 @@ func findPathToBaseCnt(
 		a, b := pr[0], pr[1]
 
 		// Try to find paths from both ingredients to base elements
-		if findPathToBaseCnt(a, depth+1, maxDepth, g, recipes, visit, counter) &&
-			findPathToBaseCnt(b, depth+1, maxDepth, g, recipes, visit, counter) {
+		if findPathToBaseCnt(ctx, a, depth+1, maxDepth, g, recipes, visit, canReach, counter, t) &&
+			findPathToBaseCnt(ctx, b, depth+1, maxDepth, g, recipes, visit, canReach, counter, t) {
 
 			// Record the successful recipe step
 			recipes[name] = RecipeStep{
@@ -129,13 +192,19 @@ func findPathToBaseCnt(
 					B: g.IDToName[b],
 				},
 			}
-			canReachBaseCache[id] = true
+			t.OnDiscover(id, a, b)
+			canReach[id] = true
 			return true
 		}
+		if ctx.Err() == nil {
+			t.OnPrune(EventBacktrack, id, a, b)
+		}
 	}
 
-	// No valid path found
-	canReachBaseCache[id] = false
+	// No valid path found (unless we only gave up because of cancellation)
+	if ctx.Err() == nil {
+		canReach[id] = false
+	}
 	return false
 }
 
@@ -143,31 +212,44 @@ func findPathToBaseCnt(
 // It starts from the target element and works backward to find constituent ingredients
 // until reaching base elements.
 // Parameters:
+//   - ctx: Cancels the search (time budget or client disconnect)
 //   - target: Name of the target element to find a recipe for
 //   - g: The indexed graph containing all element relationships
 //
 // Returns:
 //   - ProductToIngredients: Map of products to their ingredient recipes
 //   - int: Count of nodes visited during the search
-func DFSBuildTargetToBase(target string, g IndexedGraph) (ProductToIngredients, int) {
+func DFSBuildTargetToBase(ctx context.Context, target string, g IndexedGraph) (ProductToIngredients, int) {
+	return DFSBuildTargetToBaseTrace(ctx, target, g, nil)
+}
+
+// DFSBuildTargetToBaseTrace is DFSBuildTargetToBase that also reports every
+// push, pop, cache hit, cycle rejection and backtrack to t (may be nil).
+func DFSBuildTargetToBaseTrace(ctx context.Context, target string, g IndexedGraph, t Tracer) (ProductToIngredients, int) {
+	t = orNoop(t)
 	targetID := g.NameToID[target]
 	recipes := make(ProductToIngredients)
 	visited := make(map[int]bool)
 
-	// Initialize cache with base elements (they can reach themselves)
-	canReachBaseCache = map[int]bool{}
+	// Initialize cache with base elements (they can reach themselves). The
+	// cache belongs to this search, so searches can run concurrently.
+	canReach := map[int]bool{}
 	for _, b := range BaseElements {
-		canReachBaseCache[g.NameToID[b]] = true
+		canReach[g.NameToID[b]] = true
 	}
 
 	// Start DFS with nodes counter
 	nodes := 0
 
 	// First try with reasonable depth limit
-	if !findPathToBaseCnt(targetID, 0, 1000, g, recipes, visited, &nodes) {
+	found := findPathToBaseCnt(ctx, targetID, 0, 1000, g, recipes, visited, canReach, &nodes, t)
+	if !found && ctx.Err() == nil {
 		// If that fails, try again with much higher limit
 		visited = map[int]bool{}
-		findPathToBaseCnt(targetID, 0, 10000, g, recipes, visited, &nodes)
+		found = findPathToBaseCnt(ctx, targetID, 0, 10000, g, recipes, visited, canReach, &nodes, t)
+	}
+	if found {
+		t.OnSolution(targetID)
 	}
 
 	return recipes, nodes
@@ -181,14 +263,28 @@ Multi-path DFS (iterative/parallel)
 //
 // For each reverse-combination (root pair) that produces the target, a separate
 // goroutine is launched to recursively explore all possible ingredient paths back
-// to base elements. A bounded worker pool (limited to NumCPU()) ensures controlled
-// parallelism.
+// to base elements. A bounded worker pool (opts.Workers, one per CPU by default)
+// ensures controlled parallelism.
 //
 // Each path is deduplicated using a hash signature to guarantee uniqueness.
 // Once maxPaths unique results are found, all active searches are cancelled early.
-func RangeDFSPaths(target string, maxPaths int, g IndexedGraph) ([]RecipeStep, int) {
+// Cancelling the parent ctx stops every goroutine and returns the paths found so far.
+func RangeDFSPaths(parent context.Context, target string, maxPaths int, g IndexedGraph) ([]RecipeStep, int) {
+	return RangeDFSPathsStream(parent, target, maxPaths, g, MultiPathOptions{}, nil, nil)
+}
+
+// RangeDFSPathsStream is RangeDFSPaths that also hands every unique path to
+// onPath the moment it is found (used for streaming responses), and every
+// push, pop and cycle rejection to t. Steps from the parallel workers
+// interleave; OnSolution on the target marks each new unique path.
+// Calls to onPath and t are serialized; nil callbacks are ignored.
+func RangeDFSPathsStream(parent context.Context, target string, maxPaths int, g IndexedGraph, opts MultiPathOptions, onPath func(RecipeStep), t Tracer) ([]RecipeStep, int) {
+	t = syncTracer(t)
+	if opts.Deterministic {
+		return rangeDFSPathsOrdered(parent, target, maxPaths, g, opts, onPath, t)
+	}
 	targetID := g.NameToID[target]
-	roots := revIdx[targetID]
+	roots := g.reverseIndex()[targetID]
 
 	var (
 		out     []RecipeStep
@@ -197,8 +293,8 @@ func RangeDFSPaths(target string, maxPaths int, g IndexedGraph) ([]RecipeStep, i
 		nodes   int64
 	)
 
-	sem := make(chan struct{}, runtime.NumCPU())
-	ctx, cancel := context.WithCancel(context.Background())
+	sem := make(chan struct{}, opts.workers())
+	ctx, cancel := context.WithCancel(parent)
 	defer cancel()
 
 	var wg sync.WaitGroup
@@ -212,6 +308,8 @@ func RangeDFSPaths(target string, maxPaths int, g IndexedGraph) ([]RecipeStep, i
 		}
 
 		atomic.AddInt64(&nodes, 1)
+		t.OnEnqueue(id)
+		defer t.OnVisit(id)
 
 		if isBaseID(id, g) {
 			sig := hashPath(path)
@@ -219,7 +317,12 @@ func RangeDFSPaths(target string, maxPaths int, g IndexedGraph) ([]RecipeStep, i
 			if len(out) < maxPaths {
 				if _, dup := seenSig[sig]; !dup {
 					seenSig[sig] = struct{}{}
-					out = append(out, buildRecipeStepFromPath(path, targetID, g))
+					step := buildRecipeStepFromPath(path, targetID, g)
+					out = append(out, step)
+					t.OnSolution(targetID)
+					if onPath != nil {
+						onPath(step)
+					}
 					if len(out) == maxPaths {
 						cancel()
 					}
@@ -230,12 +333,13 @@ func RangeDFSPaths(target string, maxPaths int, g IndexedGraph) ([]RecipeStep, i
 		}
 
 		if visited[id] {
+			t.OnPrune(EventCycle, id, 0, 0)
 			return
 		}
 		visited[id] = true
 		defer func() { visited[id] = false }()
 
-		for _, pr := range revIdx[id] {
+		for _, pr := range g.reverseIndex()[id] {
 			newPath := append(path, []int{pr.a, pr.b, id})
 			dfs(pr.a, newPath, visited)
 			dfs(pr.b, newPath, visited)
@@ -243,11 +347,16 @@ func RangeDFSPaths(target string, maxPaths int, g IndexedGraph) ([]RecipeStep, i
 	}
 
 	for _, pr := range roots {
+		if ctx.Err() != nil {
+			break // no point in launching more workers
+		}
 		sem <- struct{}{}
 		wg.Add(1)
+		rangeDFSGoroutines.start()
 		go func(pr pair) {
 			defer wg.Done()
 			defer func() { <-sem }()
+			defer rangeDFSGoroutines.done()
 
 			visited := make(map[int]bool)
 			initial := [][]int{{pr.a, pr.b, targetID}}
@@ -260,6 +369,127 @@ func RangeDFSPaths(target string, maxPaths int, g IndexedGraph) ([]RecipeStep, i
 	return out, int(atomic.LoadInt64(&nodes))
 }
 
+// rangeDFSPathsOrdered is the deterministic RangeDFSPathsStream. Every root
+// pair collects its own paths (in DFS order, up to maxPaths) and finished
+// roots are merged strictly in root order, so scheduling never changes which
+// paths are kept. Workers stop once the merged roots hold maxPaths paths.
+// The node count only covers merged roots, which makes it reproducible too.
+func rangeDFSPathsOrdered(parent context.Context, target string, maxPaths int, g IndexedGraph, opts MultiPathOptions, onPath func(RecipeStep), t Tracer) ([]RecipeStep, int) {
+	targetID := g.NameToID[target]
+	roots := g.reverseIndex()[targetID]
+
+	type rootResult struct {
+		paths []RecipeStep
+		sigs  []uint64
+		nodes int
+		done  bool // explored completely (or up to maxPaths), not cancelled
+	}
+	results := make([]rootResult, len(roots))
+
+	var (
+		out     []RecipeStep
+		seenSig = make(map[uint64]struct{})
+		merged  int // roots[:merged] are already in out
+		nodes   int
+		mu      sync.Mutex
+	)
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	// merge moves finished roots into out, in order (caller holds mu)
+	merge := func() {
+		for merged < len(roots) && results[merged].done && len(out) < maxPaths {
+			res := results[merged]
+			nodes += res.nodes
+			for i, step := range res.paths {
+				if len(out) == maxPaths {
+					break
+				}
+				if _, dup := seenSig[res.sigs[i]]; dup {
+					continue
+				}
+				seenSig[res.sigs[i]] = struct{}{}
+				out = append(out, step)
+				t.OnSolution(targetID)
+				if onPath != nil {
+					onPath(step)
+				}
+			}
+			merged++
+		}
+		if len(out) == maxPaths {
+			cancel() // later roots can't change the result any more
+		}
+	}
+
+	sem := make(chan struct{}, opts.workers())
+	var wg sync.WaitGroup
+
+	for i, pr := range roots {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		rangeDFSGoroutines.start()
+		go func(i int, pr pair) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer rangeDFSGoroutines.done()
+
+			var res rootResult
+			local := make(map[uint64]bool)
+
+			var dfs func(id int, path [][]int, visited map[int]bool)
+			dfs = func(id int, path [][]int, visited map[int]bool) {
+				if ctx.Err() != nil || len(res.paths) == maxPaths {
+					return
+				}
+				res.nodes++
+				t.OnEnqueue(id)
+				defer t.OnVisit(id)
+
+				if isBaseID(id, g) {
+					if sig := hashPath(path); !local[sig] {
+						local[sig] = true
+						res.paths = append(res.paths, buildRecipeStepFromPath(path, targetID, g))
+						res.sigs = append(res.sigs, sig)
+					}
+					return
+				}
+
+				if visited[id] {
+					t.OnPrune(EventCycle, id, 0, 0)
+					return
+				}
+				visited[id] = true
+				defer func() { visited[id] = false }()
+
+				for _, pr := range g.reverseIndex()[id] {
+					newPath := append(path, []int{pr.a, pr.b, id})
+					dfs(pr.a, newPath, visited)
+					dfs(pr.b, newPath, visited)
+				}
+			}
+
+			initial := [][]int{{pr.a, pr.b, targetID}}
+			visited := make(map[int]bool)
+			dfs(pr.a, initial, visited)
+			dfs(pr.b, initial, visited)
+			res.done = ctx.Err() == nil
+
+			mu.Lock()
+			results[i] = res
+			merge()
+			mu.Unlock()
+		}(i, pr)
+	}
+
+	wg.Wait()
+	return out, nodes
+}
+
 // isBaseID returns true if id corresponds to one of the base elements.
 func isBaseID(id int, g IndexedGraph) bool {
 	for _, b := range BaseElements {
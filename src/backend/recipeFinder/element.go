@@ -0,0 +1,279 @@
+// backend/recipeFinder/element.go
+// Per-element details: forward/reverse relationships, depth and recipe-tree counts
+
+package recipeFinder
+
+import (
+	"math/big"
+	"sort"
+)
+
+// ElementUsage is one recipe an element takes part in: element + Partner → Product
+type ElementUsage struct {
+	Partner string `json:"partner"`
+	Product string `json:"product"`
+}
+
+// ElementDetail is everything known about a single element
+type ElementDetail struct {
+	Name      string            `json:"name"`
+	Tier      string            `json:"tier"`
+	TierLevel int               `json:"tier_level"`
+	SVGPath   string            `json:"svg_path"`
+	IsBase    bool              `json:"is_base"`
+	Recipes   []IngredientCombo `json:"recipes"` // Valid recipes that make this element
+	UsedIn    []ElementUsage    `json:"used_in"` // Recipes this element is an ingredient of
+
+	// Depth is the minimal number of combination levels needed to reach the
+	// element from the base set (0 for base elements, -1 if unreachable)
+	Depth int `json:"depth"`
+
+	// RecipeTreeCount is the number of distinct full recipe trees down to
+	// the base elements. It grows very quickly, hence a big integer.
+	RecipeTreeCount *big.Int `json:"recipe_tree_count"`
+}
+
+// GetElementDetail collects the details of one element from the catalog
+// metadata and the indexed graph. The name must be canonical (see NameResolver).
+func GetElementDetail(name string, g IndexedGraph) (ElementDetail, bool) {
+	return NewElementGraph(g).Detail(name)
+}
+
+// ElementGraph answers per-element questions about one indexed graph. The
+// reverse index is built once and depths and tree counts are memoized, so
+// walking many elements (e.g. a GraphQL query) stays cheap. It is not safe
+// for concurrent use.
+type ElementGraph struct {
+	g       IndexedGraph
+	reverse revIndex
+	depth   map[int]int
+	count   map[int]*big.Int
+}
+
+// NewElementGraph prepares the per-element lookups of g
+func NewElementGraph(g IndexedGraph) *ElementGraph {
+	return &ElementGraph{
+		g:       g,
+		reverse: uniqueReverseIndex(g),
+		depth:   make(map[int]int),
+		count:   make(map[int]*big.Int),
+	}
+}
+
+// Detail collects everything known about one element (canonical name)
+func (eg *ElementGraph) Detail(name string) (ElementDetail, bool) {
+	if _, ok := eg.g.NameToID[name]; !ok {
+		return ElementDetail{}, false
+	}
+
+	meta := GlobalElementIndex[name]
+	return ElementDetail{
+		Name:            name,
+		Tier:            meta.Tier,
+		TierLevel:       getElementTier(name),
+		SVGPath:         meta.SVGPath,
+		IsBase:          isBaseElement(name),
+		Recipes:         eg.Recipes(name),
+		UsedIn:          eg.UsedIn(name),
+		Depth:           eg.Depth(name),
+		RecipeTreeCount: eg.RecipeTreeCount(name),
+	}, true
+}
+
+// Recipes returns every valid recipe that makes the element (reverse relationships)
+func (eg *ElementGraph) Recipes(name string) []IngredientCombo {
+	recipes := []IngredientCombo{}
+	id, ok := eg.g.NameToID[name]
+	if !ok {
+		return recipes
+	}
+	for _, p := range eg.reverse[id] {
+		recipes = append(recipes, IngredientCombo{A: eg.g.IDToName[p.a], B: eg.g.IDToName[p.b]})
+	}
+	return recipes
+}
+
+// UsedIn returns every product the element helps make (forward relationships),
+// sorted by product and partner
+func (eg *ElementGraph) UsedIn(name string) []ElementUsage {
+	usedIn := []ElementUsage{}
+	id, ok := eg.g.NameToID[name]
+	if !ok {
+		return usedIn
+	}
+	seen := make(map[ElementUsage]bool)
+	for _, e := range eg.g.Edges[id] {
+		usage := ElementUsage{Partner: eg.g.IDToName[e.PartnerID], Product: eg.g.IDToName[e.ProductID]}
+		if !seen[usage] {
+			seen[usage] = true
+			usedIn = append(usedIn, usage)
+		}
+	}
+	sort.Slice(usedIn, func(i, j int) bool {
+		if usedIn[i].Product != usedIn[j].Product {
+			return usedIn[i].Product < usedIn[j].Product
+		}
+		return usedIn[i].Partner < usedIn[j].Partner
+	})
+	return usedIn
+}
+
+// IsBase reports whether the element is one of the base elements
+func (eg *ElementGraph) IsBase(name string) bool {
+	return isBaseElement(name)
+}
+
+// Depth is the minimal number of combination levels needed to make the
+// element from the base set (0 for base elements, -1 if unreachable)
+func (eg *ElementGraph) Depth(name string) int {
+	id, ok := eg.g.NameToID[name]
+	if !ok {
+		return -1
+	}
+	return minimalDepth(id, eg.reverse, eg.g, eg.depth)
+}
+
+// RecipeTreeCount is the number of distinct full recipe trees of the element
+func (eg *ElementGraph) RecipeTreeCount(name string) *big.Int {
+	id, ok := eg.g.NameToID[name]
+	if !ok {
+		return new(big.Int)
+	}
+	return countRecipeTrees(id, eg.reverse, eg.g, eg.count)
+}
+
+// PathStep is one combination on a path between two elements:
+// Ingredient + Partner → Product
+type PathStep struct {
+	Ingredient string `json:"ingredient"`
+	Partner    string `json:"partner"`
+	Product    string `json:"product"`
+}
+
+// Path returns the shortest chain of combinations that leads from one
+// element to another: from is combined with a partner, that product with
+// the next partner, and so on until to is made. Partners are not required
+// to be reachable themselves. The path is empty for from == to; ok is false
+// when to can't be made that way.
+func (eg *ElementGraph) Path(from, to string) (steps []PathStep, ok bool) {
+	fromID, ok1 := eg.g.NameToID[from]
+	toID, ok2 := eg.g.NameToID[to]
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+
+	// Plain BFS over the forward edges; prev remembers how each element was reached
+	type via struct{ ingredient, partner int }
+	prev := map[int]via{fromID: {-1, -1}}
+	queue := []int{fromID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == toID {
+			break
+		}
+		for _, e := range eg.g.Edges[id] {
+			if _, seen := prev[e.ProductID]; seen {
+				continue
+			}
+			prev[e.ProductID] = via{id, e.PartnerID}
+			queue = append(queue, e.ProductID)
+		}
+	}
+	if _, reached := prev[toID]; !reached {
+		return nil, false
+	}
+
+	steps = []PathStep{}
+	for id := toID; id != fromID; id = prev[id].ingredient {
+		v := prev[id]
+		steps = append(steps, PathStep{
+			Ingredient: eg.g.IDToName[v.ingredient],
+			Partner:    eg.g.IDToName[v.partner],
+			Product:    eg.g.IDToName[id],
+		})
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps, true
+}
+
+// uniqueReverseIndex maps each product ID to its ingredient pairs, with each
+// pair listed once (the graph stores every recipe from both ingredients' side)
+func uniqueReverseIndex(g IndexedGraph) revIndex {
+	idx := make(revIndex)
+	seen := make(map[[3]int]bool)
+	for a, nbrs := range g.Edges {
+		for _, e := range nbrs {
+			p := pair{a: min(a, e.PartnerID), b: max(a, e.PartnerID)}
+			key := [3]int{p.a, p.b, e.ProductID}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			idx[e.ProductID] = append(idx[e.ProductID], p)
+		}
+	}
+
+	// Stable order: by ingredient names
+	for _, list := range idx {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].a != list[j].a {
+				return g.IDToName[list[i].a] < g.IDToName[list[j].a]
+			}
+			return g.IDToName[list[i].b] < g.IDToName[list[j].b]
+		})
+	}
+	return idx
+}
+
+// minimalDepth returns the fewest combination levels needed to make id from
+// the base elements, or -1 if it can't be made. Recipes only combine
+// lower-tier ingredients, so the recursion always terminates.
+func minimalDepth(id int, reverse revIndex, g IndexedGraph, memo map[int]int) int {
+	if d, ok := memo[id]; ok {
+		return d
+	}
+	if isBaseID(id, g) {
+		memo[id] = 0
+		return 0
+	}
+	memo[id] = -1 // guard against malformed (cyclic) data
+
+	best := -1
+	for _, p := range reverse[id] {
+		da := minimalDepth(p.a, reverse, g, memo)
+		db := minimalDepth(p.b, reverse, g, memo)
+		if da < 0 || db < 0 {
+			continue
+		}
+		if d := max(da, db) + 1; best < 0 || d < best {
+			best = d
+		}
+	}
+	memo[id] = best
+	return best
+}
+
+// countRecipeTrees returns how many distinct full recipe trees make id:
+// 1 for base elements, otherwise the sum over recipes of count(a)*count(b)
+func countRecipeTrees(id int, reverse revIndex, g IndexedGraph, memo map[int]*big.Int) *big.Int {
+	if c, ok := memo[id]; ok {
+		return c
+	}
+	if isBaseID(id, g) {
+		memo[id] = big.NewInt(1)
+		return memo[id]
+	}
+	memo[id] = big.NewInt(0) // guard against malformed (cyclic) data
+
+	total := new(big.Int)
+	for _, p := range reverse[id] {
+		ca := countRecipeTrees(p.a, reverse, g, memo)
+		cb := countRecipeTrees(p.b, reverse, g, memo)
+		total.Add(total, new(big.Int).Mul(ca, cb))
+	}
+	memo[id] = total
+	return total
+}
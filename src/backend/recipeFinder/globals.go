@@ -1,6 +1,8 @@
 // globals/globals.go
 package recipeFinder
 
+import "runtime"
+
 // ==================== BASE ELEMENTS ====================
 // Starting elements
 var BaseElements = []string{"Air", "Earth", "Fire", "Water"}
@@ -24,6 +26,8 @@ type IndexedGraph struct {
 	NameToID map[string]int          	// Maps element names to their ID
 	IDToName map[int]string          	// Reverse mapping for reconstruction
 	Edges    map[int][]IndexedNeighbor 	// Adjacency list using IDs
+
+	memo *graphMemo // Per-catalog search memos, shared by every copy of the graph
 }
 
 // ==================== RECIPE TYPES ====================
@@ -52,6 +56,27 @@ type ProductToIngredients map[string]RecipeStep
 // Maps from product to multiple possible recipes
 type ProductToMultipleIngredients map[string][]RecipeStep
 
+// ==================== SEARCH OPTIONS ====================
+// Options of the parallel multi-path searches (RangeDFSPathsStream,
+// ReversedMultiPathBFSParallelStream)
+type MultiPathOptions struct {
+	// Deterministic makes the result independent of goroutine scheduling:
+	// paths are kept in a canonical order and truncation to maxPaths always
+	// keeps the same ones. Only a search cut short by its context can differ.
+	Deterministic bool
+
+	// Workers is the number of goroutines a search runs on (0 = one per CPU)
+	Workers int
+}
+
+// workers returns the effective worker pool size
+func (o MultiPathOptions) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.NumCPU()
+}
+
 // ==================== GLOBALS ====================
 // Global indexed graph accessible throughout the package
 var GlobalIndexedGraph IndexedGraph
@@ -59,4 +84,29 @@ var GlobalIndexedGraph IndexedGraph
 // Global variable to store the catalog
 var GlobalCatalog Catalog
 
-var revIdx revIndex // Global reverse index: productID → pairs
+// graphMemo holds what the searches derive from one catalog (BuildIndexedGraph
+// and BuildReverseIndex). It travels with the graph, so a search on a graph
+// captured earlier keeps using that catalog's memos after another one is
+// activated.
+type graphMemo struct {
+	tiers            map[string]int                         // element tiers the graph was built with
+	revIdx           revIndex                               // reverse index: productID → pairs
+	reachable        map[int]bool                           // elements that can be made from the base elements
+	multiPathReverse map[int][]struct{ InputA, InputB int } // multi-path BFS reverse graph
+}
+
+// reverseIndex returns the reverse index of g (nil before BuildReverseIndex)
+func (g IndexedGraph) reverseIndex() revIndex {
+	if g.memo == nil {
+		return nil
+	}
+	return g.memo.revIdx
+}
+
+// tier returns the tier of an element in the catalog g was built from
+func (g IndexedGraph) tier(element string) int {
+	if g.memo == nil {
+		return getElementTier(element)
+	}
+	return tierIn(g.memo.tiers, element)
+}
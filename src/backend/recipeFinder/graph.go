@@ -123,6 +123,8 @@ func BuildIndexedGraph(cat Catalog) IndexedGraph {
 		NameToID: nameToID, // Name to ID mapping
 		IDToName: idToName, // ID to name mapping
 		Edges:    edges,    // Graph edges with IDs
+		// InitElementTiers makes a new map each time, so the graph keeps its own
+		memo: &graphMemo{tiers: elementTierCache},
 	}
 }
 
@@ -166,8 +168,13 @@ func InitElementTiers(cat Catalog) {
 // getElementTier returns the tier level of an element
 // Base elements have tier 0, and higher tiers increase from there
 func getElementTier(element string) int {
+	return tierIn(elementTierCache, element)
+}
+
+// tierIn is getElementTier looking the element up in tiers
+func tierIn(tiers map[string]int, element string) int {
 	// Check if element exists in cache
-	if tier, exists := elementTierCache[element]; exists {
+	if tier, exists := tiers[element]; exists {
 		return tier
 	}
 
@@ -128,7 +128,13 @@ func pathSimilarity(path1, path2 [][]string) float64 {
 }
 
 
-// TreeSignature creates a structural signature for deduplication
+// TreeSignature returns the order-independent structural signature used to
+// deduplicate recipe trees (e.g. while streaming them one by one)
+func TreeSignature(tree *RecipeNode) string {
+	return treeSignature(tree)
+}
+
+// treeSignature creates a structural signature for deduplication
 func treeSignature(tree *RecipeNode) string {
 	if tree == nil {
 		return ""
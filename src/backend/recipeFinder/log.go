@@ -0,0 +1,25 @@
+// backend/recipeFinder/log.go
+// Request-scoped logging: searches log through the logger carried by their context
+
+package recipeFinder
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerKey struct{}
+
+// WithLogger returns a context whose searches log through l (e.g. a logger
+// tagged with the request ID)
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// Logger returns the logger carried by ctx, or the default logger
+func Logger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
@@ -0,0 +1,53 @@
+// backend/recipeFinder/metrics.go
+// Goroutine counters of the parallel searches, exported for monitoring
+
+package recipeFinder
+
+import "sync/atomic"
+
+// Searches that spawn goroutines, as named in GoroutineStats
+const (
+	SearchRangeDFS    = "range_dfs"    // RangeDFSPaths / RangeDFSPathsStream
+	SearchParallelBFS = "parallel_bfs" // ReversedMultiPathBFSParallel(Stream)
+)
+
+// GoroutineCounts are the goroutines one kind of search has spawned
+type GoroutineCounts struct {
+	Started int64 // Since the process started
+	Active  int64 // Still running
+}
+
+// goroutineCounter counts the goroutines of one kind of search
+type goroutineCounter struct {
+	started, active int64
+}
+
+// start records a goroutine about to be spawned; the goroutine calls done when it exits
+func (c *goroutineCounter) start() {
+	atomic.AddInt64(&c.started, 1)
+	atomic.AddInt64(&c.active, 1)
+}
+
+func (c *goroutineCounter) done() {
+	atomic.AddInt64(&c.active, -1)
+}
+
+var (
+	rangeDFSGoroutines    goroutineCounter
+	parallelBFSGoroutines goroutineCounter
+)
+
+// GoroutineStats returns the goroutine counts of every parallel search, by search name
+func GoroutineStats() map[string]GoroutineCounts {
+	stats := make(map[string]GoroutineCounts, 2)
+	for name, c := range map[string]*goroutineCounter{
+		SearchRangeDFS:    &rangeDFSGoroutines,
+		SearchParallelBFS: &parallelBFSGoroutines,
+	} {
+		stats[name] = GoroutineCounts{
+			Started: atomic.LoadInt64(&c.started),
+			Active:  atomic.LoadInt64(&c.active),
+		}
+	}
+	return stats
+}
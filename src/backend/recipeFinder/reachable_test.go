@@ -0,0 +1,88 @@
+package recipeFinder
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// ghostCatalog is the synthetic catalog plus elements built on Ghost, which
+// has no recipe: Haunt (only made from Ghost) can't be made, Mist and Fog can
+// but have recipes with an ingredient that can't be made
+func ghostCatalog() Catalog {
+	cat := syntheticCatalog()
+	cat.Tiers = append(cat.Tiers,
+		Tier{Name: "6", Elements: []Element{{Name: "Ghost"}}},
+		Tier{Name: "7", Elements: []Element{
+			{Name: "Haunt", Recipes: [][]string{{"Ghost", "Air"}}},
+			{Name: "Mist", Recipes: [][]string{{"Ghost", "Water"}, {"Water", "Air"}}},
+		}},
+		Tier{Name: "8", Elements: []Element{
+			{Name: "Fog", Recipes: [][]string{{"Mist", "Haunt"}, {"Mist", "Cloud"}}},
+		}},
+	)
+	return cat
+}
+
+// TestReverseIndexMemos checks the per-catalog memos: which elements can be
+// made, and reverse graphs that keep every recipe, made or not
+func TestReverseIndexMemos(t *testing.T) {
+	cat := ghostCatalog()
+	InitElementTiers(cat)
+	g := BuildIndexedGraph(cat)
+	BuildReverseIndex(g)
+	defer loadSyntheticCatalog() // leave the package state the other tests expect
+
+	for name, want := range map[string]bool{"Air": true, "Ghost": false, "Haunt": false, "Mist": true, "Fog": true} {
+		if got := !g.unreachable(g.NameToID[name]); got != want {
+			t.Errorf("%s: reachable = %v, want %v", name, got, want)
+		}
+	}
+
+	ghost := g.NameToID["Ghost"]
+	withGhost := 0
+	for _, p := range g.memo.revIdx[g.NameToID["Mist"]] {
+		if p.a == ghost || p.b == ghost {
+			withGhost++
+		}
+	}
+	if withGhost == 0 {
+		t.Errorf("Mist: reverse index lost the pair with Ghost: %v", g.memo.revIdx[g.NameToID["Mist"]])
+	}
+	if !reflect.DeepEqual(g.memo.multiPathReverse, buildReverseGraph(g)) {
+		t.Error("the shared multi-path reverse graph differs from the one a search would build")
+	}
+}
+
+// TestReachableMemoKeepsResults runs the searches with and without the
+// reachability memo: it only cuts short DFS branches that can never reach the
+// base elements, so the results must stay the same. The single DFS picks
+// among equally simple recipes in map order, so only whether it finds one
+// is compared.
+func TestReachableMemoKeepsResults(t *testing.T) {
+	cat := ghostCatalog()
+	InitElementTiers(cat)
+	g := BuildIndexedGraph(cat)
+	BuildReverseIndex(g)
+	defer loadSyntheticCatalog()
+
+	memo := g.memo.reachable
+	for _, target := range []string{"Haunt", "Mist", "Fog", "Brick", "Chicken"} {
+		g.memo.reachable = memo
+		with := runSearches(g, target)
+		g.memo.reachable = nil
+		without := runSearches(g, target)
+		if (len(with.singleDFS) == 0) != (len(without.singleDFS) == 0) {
+			t.Errorf("%s: single DFS found %v with the reachability memo, %v without", target, with.singleDFS, without.singleDFS)
+		}
+		with.singleDFS, without.singleDFS = nil, nil
+		if !reflect.DeepEqual(with, without) {
+			t.Errorf("%s: results differ with the reachability memo:\n with %+v\n  without %+v", target, with, without)
+		}
+	}
+
+	g.memo.reachable = memo
+	if rec, _ := DFSBuildTargetToBase(context.Background(), "Haunt", g); len(rec) != 0 {
+		t.Errorf("Haunt: single DFS found %v, want nothing", rec)
+	}
+}
@@ -0,0 +1,232 @@
+// backend/recipeFinder/resolver.go
+// Forgiving element name lookup: case-insensitive, whitespace/underscore tolerant, with aliases
+
+package recipeFinder
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultAliases maps common alternative spellings to canonical wiki names.
+// Keys are normalized with normalizeName before use; real element names always win over aliases.
+var DefaultAliases = map[string]string{
+	"h2o":    "Water",
+	"aqua":   "Water",
+	"humans": "Human",
+}
+
+// NameResolver turns user input into a canonical element name from the graph
+type NameResolver struct {
+	exact        map[string]bool   // canonical names as they appear in the graph
+	byNormalized map[string]string // normalized name → canonical name
+	aliases      map[string]string // normalized alias → canonical name
+	names        []string          // all canonical names, sorted (for suggestions)
+}
+
+// Global resolver built alongside GlobalIndexedGraph
+var GlobalNameResolver *NameResolver
+
+// BuildNameResolver indexes every element of the graph plus the given aliases.
+// Aliases pointing at elements that don't exist in the graph are ignored.
+func BuildNameResolver(g IndexedGraph, aliases map[string]string) *NameResolver {
+	r := &NameResolver{
+		exact:        make(map[string]bool, len(g.NameToID)),
+		byNormalized: make(map[string]string, len(g.NameToID)),
+		aliases:      make(map[string]string, len(aliases)),
+		names:        make([]string, 0, len(g.NameToID)),
+	}
+
+	for name := range g.NameToID {
+		r.exact[name] = true
+		r.byNormalized[normalizeName(name)] = name
+		r.names = append(r.names, name)
+	}
+	sort.Strings(r.names)
+
+	for alias, canonical := range aliases {
+		if _, ok := g.NameToID[canonical]; ok {
+			r.aliases[normalizeName(alias)] = canonical
+		}
+	}
+	return r
+}
+
+// Resolve returns the canonical element name for a query.
+// Exact names win, then normalized names, then aliases.
+func (r *NameResolver) Resolve(query string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	if r.exact[query] {
+		return query, true
+	}
+	key := normalizeName(query)
+	if key == "" {
+		return "", false
+	}
+	if name, ok := r.byNormalized[key]; ok {
+		return name, true
+	}
+	if name, ok := r.aliases[key]; ok {
+		return name, true
+	}
+	return "", false
+}
+
+// Suggest returns up to limit canonical names closest to the query,
+// for "did you mean" messages. Names sharing a prefix rank first,
+// then names by edit distance.
+func (r *NameResolver) Suggest(query string, limit int) []string {
+	if r == nil || limit <= 0 {
+		return nil
+	}
+	key := normalizeName(query)
+	if key == "" {
+		return nil
+	}
+
+	type candidate struct {
+		name  string
+		score int
+	}
+	// Allow roughly one typo per three characters
+	maxDist := len(key)/3 + 1
+
+	var cands []candidate
+	for _, name := range r.names {
+		norm := normalizeName(name)
+		switch {
+		case strings.HasPrefix(norm, key):
+			cands = append(cands, candidate{name, 0})
+		default:
+			if d := editDistance(key, norm); d <= maxDist {
+				cands = append(cands, candidate{name, d})
+			}
+		}
+	}
+
+	sort.SliceStable(cands, func(i, j int) bool {
+		if cands[i].score != cands[j].score {
+			return cands[i].score < cands[j].score
+		}
+		return len(cands[i].name) < len(cands[j].name)
+	})
+
+	out := make([]string, 0, limit)
+	for _, c := range cands {
+		if len(out) == limit {
+			break
+		}
+		out = append(out, c.name)
+	}
+	return out
+}
+
+// Kinds of matches returned by Search, best first
+const (
+	MatchExact     = "exact"
+	MatchPrefix    = "prefix"
+	MatchSubstring = "substring"
+	MatchFuzzy     = "fuzzy"
+)
+
+// NameMatch is one ranked result of Search
+type NameMatch struct {
+	Name  string `json:"name"`
+	Match string `json:"match"` // One of the Match* constants
+	Score int    `json:"score"` // Lower is better
+}
+
+// Search ranks element names against a query for autocomplete:
+// exact and alias matches first, then prefix, substring and finally
+// edit-distance matches. Ties are broken by shorter, then alphabetical names.
+// An empty query returns every name. A limit <= 0 means no limit.
+func (r *NameResolver) Search(query string, limit int) []NameMatch {
+	if r == nil {
+		return nil
+	}
+	key := normalizeName(query)
+
+	var out []NameMatch
+	if key == "" {
+		for _, name := range r.names {
+			out = append(out, NameMatch{Name: name, Match: MatchPrefix})
+		}
+	} else {
+		aliasTarget := r.aliases[key]
+		maxDist := len(key)/3 + 1
+		for _, name := range r.names {
+			norm := normalizeName(name)
+			switch {
+			case norm == key || name == aliasTarget:
+				out = append(out, NameMatch{Name: name, Match: MatchExact, Score: 0})
+			case strings.HasPrefix(norm, key):
+				out = append(out, NameMatch{Name: name, Match: MatchPrefix, Score: 100})
+			case strings.Contains(norm, key):
+				// Matches at the start of a word rank above ones in the middle
+				score := 300
+				if strings.Contains(norm, " "+key) {
+					score = 200
+				}
+				out = append(out, NameMatch{Name: name, Match: MatchSubstring, Score: score})
+			default:
+				// Compare against the name cut to the query length too, so
+				// typos in partially typed names still match
+				d := editDistance(key, norm)
+				if len(norm) > len(key) {
+					d = min(d, editDistance(key, norm[:len(key)]))
+				}
+				if d <= maxDist {
+					out = append(out, NameMatch{Name: name, Match: MatchFuzzy, Score: 400 + d})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score < out[j].Score
+		}
+		if len(out[i].Name) != len(out[j].Name) {
+			return len(out[i].Name) < len(out[j].Name)
+		}
+		return out[i].Name < out[j].Name
+	})
+
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// normalizeName lowercases a name, treats underscores as spaces and
+// collapses runs of whitespace, so "  hot_AIR " and "Hot Air" match.
+func normalizeName(s string) string {
+	s = strings.ReplaceAll(s, "_", " ")
+	fields := strings.FieldsFunc(s, unicode.IsSpace)
+	return strings.ToLower(strings.Join(fields, " "))
+}
+
+// editDistance computes the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(min(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
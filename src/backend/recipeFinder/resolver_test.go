@@ -0,0 +1,122 @@
+package recipeFinder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNameResolverResolve(t *testing.T) {
+	r := BuildNameResolver(loadSyntheticCatalog(), map[string]string{
+		"H2O":        "Water",
+		"hot water":  "Steam",
+		"mud":        "Steam",    // a real name wins over an alias
+		"dinosaur":   "Dinosaur", // not in the graph: ignored
+		"tall__WALL": "Wall",
+	})
+
+	cases := []struct {
+		query, want string
+	}{
+		{"Brick", "Brick"},
+		{"brick", "Brick"},
+		{"  bRiCk\t", "Brick"},
+		{"h2o", "Water"},
+		{" Hot_Water ", "Steam"},
+		{"MUD", "Mud"},
+		{"tall wall", "Wall"},
+		{"dinosaur", ""},
+		{"Bricks", ""},
+		{"   ", ""},
+	}
+	for _, c := range cases {
+		got, ok := r.Resolve(c.query)
+		if got != c.want || ok != (c.want != "") {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", c.query, got, ok, c.want)
+		}
+	}
+
+	var none *NameResolver // before the first catalog is loaded
+	if _, ok := none.Resolve("Brick"); ok {
+		t.Error("nil resolver resolved a name")
+	}
+}
+
+func TestNameResolverSuggest(t *testing.T) {
+	r := BuildNameResolver(loadSyntheticCatalog(), nil)
+
+	cases := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{"Brik", 5, []string{"Brick"}},
+		{"st", 5, []string{"Steam", "Stone"}}, // prefix matches, shorter first
+		{"Chiken", 5, []string{"Chicken"}},
+		{"wal", 1, []string{"Wall"}},
+		{"zzzzzz", 5, []string{}},
+		{"", 5, nil},
+		{"Brick", 0, nil},
+	}
+	for _, c := range cases {
+		if got := r.Suggest(c.query, c.limit); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Suggest(%q, %d) = %q, want %q", c.query, c.limit, got, c.want)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	for in, want := range map[string]string{
+		"Hot Air":       "hot air",
+		"  hot_AIR ":    "hot air",
+		"hot \t\n  air": "hot air",
+		"__":            "",
+	} {
+		if got := normalizeName(in); got != want {
+			t.Errorf("normalizeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNameResolverSearch(t *testing.T) {
+	r := BuildNameResolver(loadSyntheticCatalog(), map[string]string{"h2o": "Water"})
+
+	names := func(matches []NameMatch) []string {
+		var out []string
+		for _, m := range matches {
+			out = append(out, m.Name+" "+m.Match)
+		}
+		return out
+	}
+	cases := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		// Exact first, then prefixes by length, then close typos
+		{"egg", 0, []string{"Egg exact", "Earth fuzzy", "Energy fuzzy"}},
+		{"st", 0, []string{"Steam prefix", "Stone prefix", "Sand fuzzy"}},
+		{"H2O", 1, []string{"Water exact"}},
+		{"ick", 0, []string{"Brick substring", "Chicken substring"}},
+		// A typo in a partly typed name still matches
+		{"Chik", 0, []string{"Chicken fuzzy"}},
+		{"s", 2, []string{"Sand prefix", "Steam prefix"}},
+		{"qqqqqqq", 0, nil},
+	}
+	for _, c := range cases {
+		if got := names(r.Search(c.query, c.limit)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Search(%q, %d) = %q, want %q", c.query, c.limit, got, c.want)
+		}
+	}
+
+	// An empty query lists everything in name order
+	all := r.Search("", 0)
+	if len(all) != len(r.names) || all[0].Name != "Air" {
+		t.Errorf("empty query: %d matches, first %+v", len(all), all[0])
+	}
+	for i := 1; i < len(all); i++ {
+		if len(all[i-1].Name) > len(all[i].Name) ||
+			len(all[i-1].Name) == len(all[i].Name) && all[i-1].Name > all[i].Name {
+			t.Errorf("empty query: %s before %s", all[i-1].Name, all[i].Name)
+		}
+	}
+}
@@ -4,8 +4,10 @@
 package recipeFinder
 
 import (
+	"context"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -39,24 +41,109 @@ type Catalog struct {
 	Tiers []Tier `json:"tiers"` // List of all tiers in the game
 }
 
+// Kinds of parse warnings collected while scraping
+const (
+	WarnTierWithoutTable = "tier_without_table" // Tier header with no element table after it
+	WarnEmptyTier        = "empty_tier"         // Tier table without any usable rows
+	WarnSkippedRow       = "skipped_row"        // Table row that could not be parsed into an element
+	WarnRecipeArity      = "recipe_arity"       // Recipe list item without exactly two ingredients
+	WarnElementNoRecipes = "element_no_recipes" // Non-starting element without any recipes
+)
+
+// ScrapeWarning describes one piece of wiki markup the scraper had to skip.
+// A handful of warnings is normal; a flood of them usually means Fandom changed its layout.
+type ScrapeWarning struct {
+	Kind    string `json:"kind"`              // One of the Warn* constants
+	Tier    string `json:"tier,omitempty"`    // Tier the warning belongs to
+	Element string `json:"element,omitempty"` // Element name, if known
+	Row     int    `json:"row,omitempty"`     // Table row index, if relevant
+	Message string `json:"message"`           // Human readable description
+}
+
+// ScrapeLimits are the minimum sizes a scraped catalog must reach to be accepted.
+// A zero value disables that particular check.
+type ScrapeLimits struct {
+	MinTiers    int
+	MinElements int
+	MinRecipes  int
+}
+
+// DefaultScrapeLimits roughly matches the size of the Little Alchemy 2 wiki (720 elements)
+var DefaultScrapeLimits = ScrapeLimits{MinTiers: 10, MinElements: 500, MinRecipes: 1000}
+
+// ScrapeReport summarises a scrape: catalog size and all parse warnings
+type ScrapeReport struct {
+	Tiers    int             `json:"tiers"`
+	Elements int             `json:"elements"`
+	Recipes  int             `json:"recipes"`
+	Warnings []ScrapeWarning `json:"warnings"`
+}
+
 // ScrapeAll retrieves and parses the entire Little Alchemy 2 wiki
 // It extracts all elements, their recipes, and image references
-// Returns a complete Catalog and any errors encountered during scraping
-func ScrapeAll(downloadSVGs bool) (Catalog, error) {
+// Returns a complete Catalog, a report with all parse warnings, and an error if
+// the request failed or the catalog is smaller than the given limits.
+// Cancelling ctx aborts the scrape (including SVG downloads) with ctx.Err().
+func ScrapeAll(ctx context.Context, downloadSVGs bool, limits ScrapeLimits) (Catalog, ScrapeReport, error) {
 	// First make an HTTP GET request to the wiki page
-	resp, err := http.Get(baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL, nil)
+	if err != nil {
+		return Catalog{}, ScrapeReport{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return Catalog{}, err
+		return Catalog{}, ScrapeReport{}, err
 	}
 	defer resp.Body.Close()
 
 	// Parse the HTML document using goquery
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
-		return Catalog{}, err
+		return Catalog{}, ScrapeReport{}, err
+	}
+
+	catalog, warnings := parseCatalog(ctx, doc, downloadSVGs)
+	if err := ctx.Err(); err != nil {
+		return Catalog{}, ScrapeReport{}, err // SVG downloads were cut short
+	}
+
+	report := ScrapeReport{Tiers: len(catalog.Tiers), Warnings: warnings}
+	report.Elements, report.Recipes = CountElements(catalog)
+	if report.Warnings == nil {
+		report.Warnings = []ScrapeWarning{}
 	}
 
+	if err := limits.check(report); err != nil {
+		return catalog, report, err
+	}
+	return catalog, report, nil
+}
+
+// check returns an error if the report is below any of the minimums
+func (l ScrapeLimits) check(r ScrapeReport) error {
+	if l.MinTiers > 0 && r.Tiers < l.MinTiers {
+		return fmt.Errorf("scraped only %d tiers (minimum %d), %d warnings - wiki layout may have changed",
+			r.Tiers, l.MinTiers, len(r.Warnings))
+	}
+	if l.MinElements > 0 && r.Elements < l.MinElements {
+		return fmt.Errorf("scraped only %d elements (minimum %d), %d warnings - wiki layout may have changed",
+			r.Elements, l.MinElements, len(r.Warnings))
+	}
+	if l.MinRecipes > 0 && r.Recipes < l.MinRecipes {
+		return fmt.Errorf("scraped only %d recipes (minimum %d), %d warnings - wiki layout may have changed",
+			r.Recipes, l.MinRecipes, len(r.Warnings))
+	}
+	return nil
+}
+
+// parseCatalog extracts all tiers and elements from the wiki document,
+// collecting a warning for every piece of markup it had to skip
+func parseCatalog(ctx context.Context, doc *goquery.Document, downloadSVGs bool) (Catalog, []ScrapeWarning) {
 	var catalog Catalog
+	var warnings []ScrapeWarning
+	warn := func(w ScrapeWarning) {
+		warnings = append(warnings, w)
+	}
 
 	// Find all h3 headers which divide elements into tiers
 	doc.Find("h3").Each(func(_ int, hdr *goquery.Selection) {
@@ -72,13 +159,19 @@ func ScrapeAll(downloadSVGs bool) (Catalog, error) {
 		for tbl.Length() > 0 && !tbl.Is("table.list-table") {
 			tbl = tbl.Next()
 		}
-		if tbl.Length() == 0 {
-			return // No table found for this tier
-		}
-
 		// Process the tier name and prepare directory for SVG files
 		tierName := cleanTierName(rawTitle)
 
+		if tbl.Length() == 0 {
+			// No table found for this tier
+			warn(ScrapeWarning{
+				Kind:    WarnTierWithoutTable,
+				Tier:    tierName,
+				Message: fmt.Sprintf("no element table found after header %q", rawTitle),
+			})
+			return
+		}
+
 		// Skip the "Special" tier entirely
 		if tierName == "Special" {
 			return // Skip this tier and continue to the next h3
@@ -97,13 +190,27 @@ func ScrapeAll(downloadSVGs bool) (Catalog, error) {
 			// Get all columns in this row
 			cols := row.Find("td")
 			if cols.Length() < 2 {
-				return // Skip rows without enough columns
+				// Skip rows without enough columns
+				warn(ScrapeWarning{
+					Kind:    WarnSkippedRow,
+					Tier:    tierName,
+					Row:     i,
+					Message: fmt.Sprintf("row has %d columns, expected at least 2", cols.Length()),
+				})
+				return
 			}
 
 			// Extract element name from the first column
 			name := cols.Eq(0).Find("a[title]").First().Text()
 			if name == "" {
-				return // Skip unnamed elements
+				// Skip unnamed elements
+				warn(ScrapeWarning{
+					Kind:    WarnSkippedRow,
+					Tier:    tierName,
+					Row:     i,
+					Message: "row has no element link in its first column",
+				})
+				return
 			}
 
 			// Extract SVG image link
@@ -116,7 +223,7 @@ func ScrapeAll(downloadSVGs bool) (Catalog, error) {
 				local = filepath.Join(strings.ReplaceAll(tierName, " ", "_"), fname)
 
 				if downloadSVGs {
-					downloadSVG(href, filepath.Join(tierDir, fname)) // Commented out
+					downloadSVG(ctx, href, filepath.Join(tierDir, fname)) // Commented out
 				}
 			}
 
@@ -129,8 +236,25 @@ func ScrapeAll(downloadSVGs bool) (Catalog, error) {
 				})
 				if len(parts) == 2 {
 					recipes = append(recipes, []string{parts[0], parts[1]})
+				} else {
+					warn(ScrapeWarning{
+						Kind:    WarnRecipeArity,
+						Tier:    tierName,
+						Element: name,
+						Row:     i,
+						Message: fmt.Sprintf("recipe %q has %d ingredients, expected 2", strings.TrimSpace(li.Text()), len(parts)),
+					})
 				}
 			})
+			if len(recipes) == 0 && tierName != "Starting" {
+				warn(ScrapeWarning{
+					Kind:    WarnElementNoRecipes,
+					Tier:    tierName,
+					Element: name,
+					Row:     i,
+					Message: "element has no recipes",
+				})
+			}
 
 			// Create Element struct with all collected data
 			elems = append(elems, Element{
@@ -147,10 +271,16 @@ func ScrapeAll(downloadSVGs bool) (Catalog, error) {
 				Name:     tierName,
 				Elements: elems,
 			})
+		} else {
+			warn(ScrapeWarning{
+				Kind:    WarnEmptyTier,
+				Tier:    tierName,
+				Message: "tier table has no usable element rows",
+			})
 		}
 	})
 
-	return catalog, nil
+	return catalog, warnings
 }
 
 // cleanTierName normalizes tier names from the wiki format
@@ -167,11 +297,16 @@ func cleanTierName(raw string) string {
 // downloadSVG downloads an SVG image from a URL and saves it to a local path
 // This function is used to create a local cache of all element images
 // Note: This function handles errors internally and logs them but doesn't return them
-func downloadSVG(url, dest string) {
+func downloadSVG(ctx context.Context, url, dest string) {
 	// Download the SVG file
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		slog.Warn("svg download failed", "url", url, "error", err)
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		log.Printf("svg GET %s: %v", url, err)
+		slog.Warn("svg download failed", "url", url, "error", err)
 		return
 	}
 	defer resp.Body.Close()
@@ -179,7 +314,7 @@ func downloadSVG(url, dest string) {
 	// Create the destination file
 	f, err := os.Create(dest)
 	if err != nil {
-		log.Printf("create %s: %v", dest, err)
+		slog.Warn("svg create failed", "path", dest, "error", err)
 		return
 	}
 	defer f.Close()
@@ -0,0 +1,109 @@
+package recipeFinder
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// wikiPage is a trimmed copy of the wiki layout with one of each kind of
+// markup the scraper has to skip
+const wikiPage = `<html><body>
+<h3><span class="mw-headline">Starting elements</span></h3>
+<table class="list-table">
+  <tr><th>Element</th><th>Recipes</th></tr>
+  <tr><td><a title="Air">Air</a></td><td>Available from the start.</td></tr>
+  <tr><td><a title="Water">Water</a></td><td>Available from the start.</td></tr>
+</table>
+<h3><span class="mw-headline">Special element</span></h3>
+<table class="list-table">
+  <tr><th>Element</th><th>Recipes</th></tr>
+  <tr><td><a title="Time">Time</a></td><td><ul><li>Unlocked by 100 elements</li></ul></td></tr>
+</table>
+<h3><span class="mw-headline">Tier 1 elements</span></h3>
+<p>Elements made from the starting ones.</p>
+<table class="list-table">
+  <tr><th>Element</th><th>Recipes</th></tr>
+  <tr><td><a title="Mist">Mist</a></td><td><ul>
+    <li><a title="Air">Air</a> + <a title="Water">Water</a></li>
+    <li><a title="Air">Air</a> + <a title="Water">Water</a> + <a title="Air">Air</a></li>
+  </ul></td></tr>
+  <tr><td>only one column</td></tr>
+  <tr><td>no link</td><td><ul><li><a title="Air">Air</a> + <a title="Air">Air</a></li></ul></td></tr>
+  <tr><td><a title="Ghost">Ghost</a></td><td>Not yet known</td></tr>
+</table>
+<h3><span class="mw-headline">Tier 2 elements</span></h3>
+<table class="list-table">
+  <tr><th>Element</th><th>Recipes</th></tr>
+</table>
+<h3><span></span></h3>
+<h3><span class="mw-headline">Tier 3 elements</span></h3>
+<p>Coming soon.</p>
+</body></html>`
+
+func TestParseCatalogWarnings(t *testing.T) {
+	// parseCatalog makes the svgs/ tier directories in the working directory
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(wikiPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog, warnings := parseCatalog(context.Background(), doc, false)
+
+	want := Catalog{Tiers: []Tier{
+		{Name: "Starting", Elements: []Element{{Name: "Air", Recipes: [][]string{}}, {Name: "Water", Recipes: [][]string{}}}},
+		{Name: "1", Elements: []Element{
+			{Name: "Mist", Recipes: [][]string{{"Air", "Water"}}},
+			{Name: "Ghost", Recipes: [][]string{}},
+		}},
+	}}
+	if !reflect.DeepEqual(catalog, want) {
+		t.Errorf("catalog %+v,\n want %+v", catalog, want)
+	}
+
+	var kinds []string
+	for _, w := range warnings {
+		kinds = append(kinds, w.Kind+" "+w.Tier+" "+w.Element)
+	}
+	wantKinds := []string{
+		WarnRecipeArity + " 1 Mist",
+		WarnSkippedRow + " 1 ",
+		WarnSkippedRow + " 1 ",
+		WarnElementNoRecipes + " 1 Ghost",
+		WarnEmptyTier + " 2 ",
+		WarnTierWithoutTable + " 3 ",
+	}
+	if !reflect.DeepEqual(kinds, wantKinds) {
+		t.Errorf("warnings:\n %q,\n want %q", kinds, wantKinds)
+	}
+	if warnings[1].Row != 2 || warnings[2].Row != 3 {
+		t.Errorf("skipped rows %d and %d, want 2 and 3", warnings[1].Row, warnings[2].Row)
+	}
+}
+
+func TestScrapeLimits(t *testing.T) {
+	report := ScrapeReport{Tiers: 12, Elements: 600, Recipes: 900, Warnings: make([]ScrapeWarning, 3)}
+
+	if err := DefaultScrapeLimits.check(report); err == nil ||
+		!strings.Contains(err.Error(), "only 900 recipes (minimum 1000), 3 warnings") {
+		t.Errorf("default limits: %v", err)
+	}
+	if err := (ScrapeLimits{MinTiers: 12, MinElements: 600}).check(report); err != nil {
+		t.Errorf("limits met: %v", err)
+	}
+	if err := (ScrapeLimits{MinTiers: 13}).check(report); err == nil || !strings.Contains(err.Error(), "only 12 tiers") {
+		t.Errorf("tier limit: %v", err)
+	}
+	if err := (ScrapeLimits{}).check(ScrapeReport{}); err != nil {
+		t.Errorf("zero limits check nothing: %v", err)
+	}
+}
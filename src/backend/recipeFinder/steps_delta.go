@@ -0,0 +1,326 @@
+// backend/recipeFinder/steps_delta.go
+// Compact SearchStep encoding: only what changed per step, with periodic keyframes,
+// recorded by DeltaTracer while the search runs
+
+package recipeFinder
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultKeyframeInterval is how often a full state snapshot is stored in delta steps
+const DefaultKeyframeInterval = 50
+
+// DiscoveredEdge records which ingredients produced an element
+type DiscoveredEdge struct {
+	ParentID  int `json:"parent_id"`
+	PartnerID int `json:"partner_id"`
+}
+
+// DeltaStep is one search step in delta form. On a keyframe Pushed, Seen and
+// Discovered hold the complete state; otherwise they hold only additions
+// relative to the previous step, Popped counts elements taken off the front
+// of the queue and Removed lists the positions of elements taken out of the
+// middle (parallel searches don't always visit in queue order), applied in
+// order after Popped and Pushed.
+type DeltaStep struct {
+	Step        int                    `json:"step"`
+	CurrentID   int                    `json:"current_id"`
+	FoundTarget bool                   `json:"found_target,omitempty"`
+	Kind        string                 `json:"kind,omitempty"`
+	Keyframe    bool                   `json:"keyframe,omitempty"`
+	Popped      int                    `json:"popped,omitempty"`
+	Pushed      []int                  `json:"pushed,omitempty"`
+	Removed     []int                  `json:"removed,omitempty"`
+	Seen        []int                  `json:"seen,omitempty"`
+	Discovered  map[int]DiscoveredEdge `json:"discovered,omitempty"`
+}
+
+// DeltaSteps is the compact replacement for []SearchStep (steps=delta).
+// Names holds every element ID referenced by the steps exactly once.
+type DeltaSteps struct {
+	Format        string         `json:"format"` // always "delta"
+	KeyframeEvery int            `json:"keyframe_every"`
+	Names         map[int]string `json:"names"`
+	Steps         []DeltaStep    `json:"steps"`
+}
+
+// DeltaTracer records a search in delta form as it runs. It follows the same
+// steps as StepTracer (an initial state, one step per visit or prune, a final
+// step for a target found by discovery) but only stores what changed since the
+// previous step, so a step costs as much as its changes rather than the whole
+// state. The full state is copied only on keyframes, every keyframeEvery steps.
+type DeltaTracer struct {
+	g             IndexedGraph
+	targetID      int
+	limit         int
+	keyframeEvery int
+
+	queue      []int
+	seen       map[int]bool
+	discovered map[int]DiscoveredEdge
+	stepNo     int // number of the last step taken
+
+	// changes since the last step
+	fromPrev int // elements of queue that were already there at the last step
+	popped   int
+	pushed   []int
+	removed  []int
+	newSeen  []int
+	newEdges map[int]DiscoveredEdge
+
+	out DeltaSteps
+}
+
+// NewDeltaTracer records up to limit delta steps of a search for targetID
+// (0 = no limit), with a keyframe every keyframeEvery steps (0 = DefaultKeyframeInterval)
+func NewDeltaTracer(g IndexedGraph, targetID, limit, keyframeEvery int) *DeltaTracer {
+	if keyframeEvery <= 0 {
+		keyframeEvery = DefaultKeyframeInterval
+	}
+	return &DeltaTracer{
+		g:             g,
+		targetID:      targetID,
+		limit:         limit,
+		keyframeEvery: keyframeEvery,
+		seen:          make(map[int]bool),
+		discovered:    make(map[int]DiscoveredEdge),
+		out: DeltaSteps{
+			Format:        "delta",
+			KeyframeEvery: keyframeEvery,
+			Names:         make(map[int]string),
+		},
+	}
+}
+
+// full reports whether no more steps will be recorded
+func (d *DeltaTracer) full() bool {
+	return d.limit > 0 && len(d.out.Steps) >= d.limit
+}
+
+func (d *DeltaTracer) name(id int) {
+	if id >= 0 {
+		d.out.Names[id] = d.g.IDToName[id]
+	}
+}
+
+// step stores the changes since the previous step, or the whole state on a keyframe
+func (d *DeltaTracer) step(currentID, stepNumber int, found bool, kind string) {
+	if d.full() {
+		return
+	}
+	d.name(currentID)
+	ds := DeltaStep{Step: stepNumber, CurrentID: currentID, FoundTarget: found, Kind: kind}
+	if len(d.out.Steps)%d.keyframeEvery == 0 {
+		ds.Keyframe = true
+		ds.Pushed = copyInts(d.queue)
+		ds.Seen = make([]int, 0, len(d.seen))
+		for id := range d.seen {
+			ds.Seen = append(ds.Seen, id)
+		}
+		sort.Ints(ds.Seen)
+		if len(d.discovered) > 0 {
+			ds.Discovered = make(map[int]DiscoveredEdge, len(d.discovered))
+			for id, e := range d.discovered {
+				ds.Discovered[id] = e
+			}
+		}
+	} else {
+		ds.Popped = d.popped
+		ds.Pushed = d.pushed
+		ds.Removed = d.removed
+		ds.Seen = d.newSeen
+		sort.Ints(ds.Seen)
+		if len(d.newEdges) > 0 {
+			ds.Discovered = d.newEdges
+		}
+	}
+	d.out.Steps = append(d.out.Steps, ds)
+
+	d.fromPrev, d.popped = len(d.queue), 0
+	d.pushed, d.removed, d.newSeen, d.newEdges = nil, nil, nil, nil
+}
+
+func (d *DeltaTracer) OnVisit(id int) {
+	if d.full() {
+		return
+	}
+	if len(d.out.Steps) == 0 {
+		d.step(-1, 0, false, "") // initial state, before the first visit
+	}
+	// Parallel searches don't always visit in queue order
+	for i, q := range d.queue {
+		if q != id {
+			continue
+		}
+		switch {
+		case i > 0:
+			d.removed = append(d.removed, i)
+			if i < d.fromPrev {
+				d.fromPrev--
+			}
+		case d.fromPrev > 0:
+			d.fromPrev--
+			d.popped++
+		default:
+			d.pushed = d.pushed[1:] // pushed and popped within one step
+		}
+		d.queue = append(d.queue[:i:i], d.queue[i+1:]...)
+		break
+	}
+	d.stepNo++
+	d.step(id, d.stepNo, id == d.targetID, "")
+}
+
+func (d *DeltaTracer) OnEnqueue(id int) {
+	if d.full() {
+		return
+	}
+	d.name(id)
+	d.queue = append(d.queue, id)
+	d.pushed = append(d.pushed, id)
+	d.markSeen(id)
+}
+
+func (d *DeltaTracer) OnDiscover(productID, parentID, partnerID int) {
+	if d.full() {
+		return
+	}
+	d.name(productID)
+	d.name(parentID)
+	d.name(partnerID)
+	d.markSeen(productID)
+	e := DiscoveredEdge{ParentID: parentID, PartnerID: partnerID}
+	if old, ok := d.discovered[productID]; ok && old == e {
+		return
+	}
+	d.discovered[productID] = e
+	if d.newEdges == nil {
+		d.newEdges = make(map[int]DiscoveredEdge)
+	}
+	d.newEdges[productID] = e
+}
+
+func (d *DeltaTracer) markSeen(id int) {
+	if !d.seen[id] {
+		d.seen[id] = true
+		d.newSeen = append(d.newSeen, id)
+	}
+}
+
+func (d *DeltaTracer) OnPrune(reason string, id, _, _ int) {
+	if d.full() {
+		return
+	}
+	if len(d.out.Steps) == 0 {
+		d.step(-1, 0, false, "")
+	}
+	d.stepNo++
+	d.step(id, d.stepNo, false, reason)
+}
+
+// OnSolution adds a final step for a target found by discovery rather than by a visit
+func (d *DeltaTracer) OnSolution(id int) {
+	if len(d.out.Steps) == 0 {
+		d.step(-1, 0, false, "")
+	}
+	if n := len(d.out.Steps); n > 0 && d.out.Steps[n-1].CurrentID == id && d.out.Steps[n-1].FoundTarget {
+		return
+	}
+	d.step(id, d.stepNo+1, true, "")
+}
+
+// Steps returns the recorded steps (at least the initial state)
+func (d *DeltaTracer) Steps() DeltaSteps {
+	if len(d.out.Steps) == 0 {
+		d.step(-1, 0, false, "")
+	}
+	return d.out
+}
+
+// StateAt reconstructs the full search step n (0-based index into Steps)
+// by replaying deltas from the closest keyframe at or before it.
+// Seen IDs come back sorted, since the original order carries no meaning.
+func (d DeltaSteps) StateAt(n int) (SearchStep, error) {
+	if n < 0 || n >= len(d.Steps) {
+		return SearchStep{}, fmt.Errorf("step %d out of range [0,%d)", n, len(d.Steps))
+	}
+
+	start := n
+	for start >= 0 && !d.Steps[start].Keyframe {
+		start--
+	}
+	if start < 0 {
+		return SearchStep{}, fmt.Errorf("no keyframe at or before step %d", n)
+	}
+
+	var (
+		queue      []int
+		seen       = make(map[int]bool)
+		discovered = make(map[int]struct{ ParentID, PartnerID int })
+	)
+	for i := start; i <= n; i++ {
+		ds := d.Steps[i]
+		if ds.Keyframe {
+			queue = copyInts(ds.Pushed)
+			seen = make(map[int]bool, len(ds.Seen))
+			discovered = make(map[int]struct{ ParentID, PartnerID int }, len(ds.Discovered))
+		} else {
+			if ds.Popped > len(queue) {
+				return SearchStep{}, fmt.Errorf("step %d pops %d of %d queued elements", i, ds.Popped, len(queue))
+			}
+			queue = append(queue[ds.Popped:len(queue):len(queue)], ds.Pushed...)
+			for _, pos := range ds.Removed {
+				if pos < 0 || pos >= len(queue) {
+					return SearchStep{}, fmt.Errorf("step %d removes position %d of %d queued elements", i, pos, len(queue))
+				}
+				queue = append(queue[:pos:pos], queue[pos+1:]...)
+			}
+		}
+		for _, id := range ds.Seen {
+			seen[id] = true
+		}
+		for id, e := range ds.Discovered {
+			discovered[id] = struct{ ParentID, PartnerID int }{e.ParentID, e.PartnerID}
+		}
+	}
+
+	last := d.Steps[n]
+	step := SearchStep{
+		CurrentID:       last.CurrentID,
+		CurrentName:     d.Names[last.CurrentID],
+		QueueIDs:        queue,
+		QueueNames:      make([]string, len(queue)),
+		SeenIDs:         make([]int, 0, len(seen)),
+		DiscoveredEdges: discovered,
+		DiscoveredNames: make(map[string]struct{ A, B string }, len(discovered)),
+		StepNumber:      last.Step,
+		FoundTarget:     last.FoundTarget,
+		Kind:            last.Kind,
+	}
+	for i, id := range queue {
+		step.QueueNames[i] = d.Names[id]
+	}
+	for id := range seen {
+		step.SeenIDs = append(step.SeenIDs, id)
+	}
+	sort.Ints(step.SeenIDs)
+	step.SeenNames = make([]string, len(step.SeenIDs))
+	for i, id := range step.SeenIDs {
+		step.SeenNames[i] = d.Names[id]
+	}
+	for id, e := range discovered {
+		step.DiscoveredNames[d.Names[id]] = struct{ A, B string }{d.Names[e.ParentID], d.Names[e.PartnerID]}
+	}
+	return step, nil
+}
+
+func copyInts(s []int) []int {
+	if len(s) == 0 {
+		return nil
+	}
+	out := make([]int, len(s))
+	copy(out, s)
+	return out
+}
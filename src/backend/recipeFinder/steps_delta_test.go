@@ -0,0 +1,111 @@
+package recipeFinder
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// teeTracer passes every call to both tracers, so they record the same search
+type teeTracer struct{ a, b Tracer }
+
+func (t teeTracer) OnVisit(id int)   { t.a.OnVisit(id); t.b.OnVisit(id) }
+func (t teeTracer) OnEnqueue(id int) { t.a.OnEnqueue(id); t.b.OnEnqueue(id) }
+func (t teeTracer) OnDiscover(productID, parentID, partnerID int) {
+	t.a.OnDiscover(productID, parentID, partnerID)
+	t.b.OnDiscover(productID, parentID, partnerID)
+}
+func (t teeTracer) OnPrune(reason string, id, parentID, partnerID int) {
+	t.a.OnPrune(reason, id, parentID, partnerID)
+	t.b.OnPrune(reason, id, parentID, partnerID)
+}
+func (t teeTracer) OnSolution(id int) { t.a.OnSolution(id); t.b.OnSolution(id) }
+
+// normalizeStep sorts the seen IDs and names (StepTracer lists each in map
+// order, independently) and gives empty collections one form, so snapshots
+// compare with DeepEqual
+func normalizeStep(s SearchStep) SearchStep {
+	s.SeenIDs = append([]int{}, s.SeenIDs...)
+	sort.Ints(s.SeenIDs)
+	s.SeenNames = append([]string{}, s.SeenNames...)
+	sort.Strings(s.SeenNames)
+	s.QueueIDs = append([]int{}, s.QueueIDs...)
+	s.QueueNames = append([]string{}, s.QueueNames...)
+	if len(s.DiscoveredEdges) == 0 {
+		s.DiscoveredEdges = nil
+	}
+	if len(s.DiscoveredNames) == 0 {
+		s.DiscoveredNames = nil
+	}
+	return s
+}
+
+// TestDeltaStepsMatchSnapshots records the same BFS and DFS searches with
+// StepTracer and DeltaTracer and checks that every step rebuilt with StateAt
+// equals the full snapshot, for several keyframe intervals (1 makes every
+// step a keyframe)
+func TestDeltaStepsMatchSnapshots(t *testing.T) {
+	g := loadSyntheticCatalog()
+	ctx := context.Background()
+
+	searches := map[string]func(target string, tr Tracer){
+		"single BFS": func(target string, tr Tracer) {
+			IndexedBFSTrace(ctx, target, g, tr)
+		},
+		"multi BFS": func(target string, tr Tracer) {
+			opts := MultiPathOptions{Workers: 4}
+			ReversedMultiPathBFSParallelStream(ctx, target, g, 8, opts, nil, tr)
+		},
+		"single DFS": func(target string, tr Tracer) {
+			DFSBuildTargetToBaseTrace(ctx, target, g, tr)
+		},
+		"multi DFS": func(target string, tr Tracer) {
+			opts := MultiPathOptions{Workers: 4}
+			RangeDFSPathsStream(ctx, target, 8, g, opts, nil, tr)
+		},
+	}
+
+	for name, search := range searches {
+		for _, target := range []string{"Air", "Brick", "House", "Egg", "Chicken"} {
+			for _, every := range []int{1, 3, DefaultKeyframeInterval} {
+				for _, limit := range []int{0, 7} {
+					targetID := g.NameToID[target]
+					full := NewStepTracer(g, targetID, limit)
+					delta := NewDeltaTracer(g, targetID, limit, every)
+					search(target, teeTracer{full, delta})
+
+					want := full.Steps()
+					got := delta.Steps()
+					if len(got.Steps) != len(want) {
+						t.Fatalf("%s %s (keyframe every %d, limit %d): %d delta steps, %d snapshots",
+							name, target, every, limit, len(got.Steps), len(want))
+					}
+					if !got.Steps[0].Keyframe {
+						t.Errorf("%s %s: first delta step is not a keyframe", name, target)
+					}
+					for i := range want {
+						step, err := got.StateAt(i)
+						if err != nil {
+							t.Fatalf("%s %s (keyframe every %d, limit %d): StateAt(%d): %v", name, target, every, limit, i, err)
+						}
+						if w, s := normalizeStep(want[i]), normalizeStep(step); !reflect.DeepEqual(s, w) {
+							t.Fatalf("%s %s (keyframe every %d, limit %d): step %d differs:\n got %+v\nwant %+v",
+								name, target, every, limit, i, s, w)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDeltaStepsStateAtOutOfRange(t *testing.T) {
+	d := NewDeltaTracer(loadSyntheticCatalog(), 0, 0, 0).Steps()
+	if _, err := d.StateAt(len(d.Steps)); err == nil {
+		t.Error("StateAt past the last step: expected an error")
+	}
+	if _, err := d.StateAt(-1); err == nil {
+		t.Error("StateAt(-1): expected an error")
+	}
+}
@@ -0,0 +1,192 @@
+// backend/recipeFinder/table.go
+// Precomputed recipe table: the single-recipe answers for every element of a snapshot
+
+package recipeFinder
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// RecipeTableEntry holds the precomputed answers for one element
+type RecipeTableEntry struct {
+	// ShortestRecipe is the tree a single-recipe BFS returns, and NodesVisited
+	// the elements that BFS expanded to find it
+	ShortestRecipe *RecipeNode `json:"shortest_recipe"`
+	NodesVisited   int         `json:"nodes_visited"`
+
+	// MinimalRecipe is the recipe tree with the fewest combinations when every
+	// ingredient is made from scratch; MinimalSteps is that number of
+	// combinations (0 for base elements, -1 and no tree if unreachable)
+	MinimalRecipe *RecipeNode `json:"minimal_recipe,omitempty"`
+	MinimalSteps  int         `json:"minimal_steps"`
+
+	// Same as ElementDetail.Depth and ElementDetail.RecipeTreeCount
+	Depth           int      `json:"depth"`
+	RecipeTreeCount *big.Int `json:"recipe_tree_count"`
+}
+
+// RecipeTable maps canonical element names to their entries. Version names
+// the catalog snapshot the table was computed from.
+type RecipeTable struct {
+	Version string                      `json:"version"`
+	Entries map[string]RecipeTableEntry `json:"entries"`
+}
+
+// Lookup returns the entry of a canonical element name
+func (t *RecipeTable) Lookup(name string) (RecipeTableEntry, bool) {
+	if t == nil {
+		return RecipeTableEntry{}, false
+	}
+	e, ok := t.Entries[name]
+	return e, ok
+}
+
+// BuildRecipeTable computes the table entry of every element of g on a pool
+// of workers goroutines (0 = one per CPU).
+//   - lock, if not nil, is held around each element: building a tree may fall
+//     back to searching GlobalIndexedGraph, so the catalog must stay put.
+//   - progress, if not nil, is called after each element with the number done
+//     so far; calls come from the workers and may be concurrent.
+//
+// If ctx is cancelled the build stops and returns ctx.Err().
+func BuildRecipeTable(
+	ctx context.Context,
+	g IndexedGraph,
+	version string,
+	workers int,
+	lock sync.Locker,
+	progress func(done, total int),
+) (*RecipeTable, error) {
+	names := make([]string, 0, len(g.NameToID))
+	for name := range g.NameToID {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Depth, counts and minimal recipes share their memo tables, so they are
+	// computed up front on this goroutine; only the BFS runs in parallel
+	reverse := uniqueReverseIndex(g)
+	depths := make(map[int]int)
+	counts := make(map[int]*big.Int)
+	minimal := newMinimalRecipes(reverse, g)
+
+	entries := make([]RecipeTableEntry, len(names))
+	for i, name := range names {
+		id := g.NameToID[name]
+		entries[i] = RecipeTableEntry{
+			MinimalRecipe:   minimal.tree(id),
+			MinimalSteps:    minimal.steps(id),
+			Depth:           minimalDepth(id, reverse, g, depths),
+			RecipeTreeCount: countRecipeTrees(id, reverse, g, counts),
+		}
+	}
+
+	// ---------- shortest BFS recipe per element, on the worker pool ----------
+	jobs := make(chan int)
+	var done int64
+	var wg sync.WaitGroup
+	for w := 0; w < (MultiPathOptions{Workers: workers}).workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if lock != nil {
+					lock.Lock()
+				}
+				if ctx.Err() == nil { // the catalog may have changed while waiting for the lock
+					prev, nodes := IndexedBFSTrace(ctx, names[i], g, nil)
+					entries[i].ShortestRecipe = BuildTree(ctx, names[i], prev)
+					entries[i].NodesVisited = nodes
+				}
+				if lock != nil {
+					lock.Unlock()
+				}
+				if progress != nil {
+					progress(int(atomic.AddInt64(&done, 1)), len(names))
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range names {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	table := &RecipeTable{Version: version, Entries: make(map[string]RecipeTableEntry, len(names))}
+	for i, name := range names {
+		table.Entries[name] = entries[i]
+	}
+	return table, nil
+}
+
+/*
+-------------------------------------------------------------------------
+Minimal-step recipes
+*/
+// minimalRecipes finds, for each element, the recipe whose full tree needs
+// the fewest combinations: steps(base) = 0, otherwise the cheapest recipe
+// a + b with steps(a) + steps(b) + 1. Ties keep the first recipe in the
+// reverse index order (by ingredient names).
+type minimalRecipes struct {
+	reverse revIndex
+	g       IndexedGraph
+	memo    map[int]int  // element → fewest combinations (-1 = unreachable)
+	choice  map[int]pair // element → recipe achieving it
+}
+
+func newMinimalRecipes(reverse revIndex, g IndexedGraph) *minimalRecipes {
+	return &minimalRecipes{reverse: reverse, g: g, memo: make(map[int]int), choice: make(map[int]pair)}
+}
+
+// steps returns the fewest combinations needed to make id, or -1
+func (m *minimalRecipes) steps(id int) int {
+	if s, ok := m.memo[id]; ok {
+		return s
+	}
+	if isBaseID(id, m.g) {
+		m.memo[id] = 0
+		return 0
+	}
+	m.memo[id] = -1 // guard against malformed (cyclic) data
+
+	best := -1
+	for _, p := range m.reverse[id] {
+		sa, sb := m.steps(p.a), m.steps(p.b)
+		if sa < 0 || sb < 0 {
+			continue
+		}
+		if s := sa + sb + 1; best < 0 || s < best {
+			best = s
+			m.choice[id] = p
+		}
+	}
+	m.memo[id] = best
+	return best
+}
+
+// tree builds the minimal recipe tree of id, or nil if it can't be made
+func (m *minimalRecipes) tree(id int) *RecipeNode {
+	if m.steps(id) < 0 {
+		return nil
+	}
+	node := &RecipeNode{Name: m.g.IDToName[id]}
+	if p, ok := m.choice[id]; ok {
+		node.Children = []*RecipeNode{m.tree(p.a), m.tree(p.b)}
+	}
+	return node
+}
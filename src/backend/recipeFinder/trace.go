@@ -0,0 +1,366 @@
+// backend/recipeFinder/trace.go
+// Search tracing: the Tracer interface every algorithm reports to, and its implementations
+
+package recipeFinder
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Kinds of step events. BFS uses the queue as its frontier, DFS the
+// recursion stack: "pushed" means entering an element, "popped" leaving it.
+const (
+	EventPushed     = "pushed"     // element added to the frontier
+	EventPopped     = "popped"     // element taken from the frontier and expanded
+	EventDiscovered = "discovered" // new recipe found: Parent + Partner → element
+	EventFound      = "found"      // the target was reached
+	EventCacheHit   = "cache_hit"  // DFS: answer for the element taken from the memo cache
+	EventCycle      = "cycle"      // DFS: element already on the current path, rejected
+	EventBacktrack  = "backtrack"  // DFS: Parent + Partner can't be made from the base set, try the next recipe
+)
+
+// StepEvent is one incremental change during a search. Unlike SearchStep it
+// only describes what changed, so it can be sent the moment it happens.
+type StepEvent struct {
+	Seq       int    `json:"seq"`                  // 0-based position in the event sequence
+	Kind      string `json:"kind"`                 // One of the Event* constants
+	ID        int    `json:"id"`                   // Element the event is about
+	Name      string `json:"name"`                 // Name of that element
+	ParentID  *int   `json:"parent_id,omitempty"`  // For "discovered"/"backtrack": first ingredient
+	Parent    string `json:"parent,omitempty"`     // Name of the first ingredient
+	PartnerID *int   `json:"partner_id,omitempty"` // For "discovered"/"backtrack": second ingredient
+	Partner   string `json:"partner,omitempty"`    // Name of the second ingredient
+}
+
+/*
+-------------------------------------------------------------------------
+Tracer interface
+*/
+// Tracer receives the steps of a search as they happen. Every algorithm
+// reports through it instead of recording steps itself, so the caller
+// decides what (if anything) is kept. Calls are never concurrent: searches
+// that run in parallel serialize them.
+type Tracer interface {
+	OnVisit(id int)                                     // element taken from the frontier ("popped")
+	OnEnqueue(id int)                                   // element added to the frontier ("pushed")
+	OnDiscover(productID, parentID, partnerID int)      // recipe Parent + Partner → Product found
+	OnPrune(reason string, id, parentID, partnerID int) // branch rejected; reason is EventCacheHit, EventCycle or EventBacktrack
+	OnSolution(id int)                                  // the target was reached / a complete path found
+}
+
+// NoopTracer discards everything; searches run at full speed with it
+type NoopTracer struct{}
+
+func (NoopTracer) OnVisit(int)                   {}
+func (NoopTracer) OnEnqueue(int)                 {}
+func (NoopTracer) OnDiscover(int, int, int)      {}
+func (NoopTracer) OnPrune(string, int, int, int) {}
+func (NoopTracer) OnSolution(int)                {}
+
+// orNoop replaces a nil tracer with NoopTracer
+func orNoop(t Tracer) Tracer {
+	if t == nil {
+		return NoopTracer{}
+	}
+	return t
+}
+
+// limitedTracer is a tracer that stops recording at a limit: once full
+// reports true, every further call is a no-op
+type limitedTracer interface {
+	Tracer
+	full() bool
+}
+
+// lockedTracer serializes calls from parallel workers. Once a limited tracer
+// is full the calls skip the lock, so a capped trace stops costing the
+// workers anything.
+type lockedTracer struct {
+	mu   sync.Mutex
+	t    Tracer
+	done int32 // set (atomically) once t is full
+}
+
+// syncTracer makes t safe for the parallel searches (NoopTracer needs no lock)
+func syncTracer(t Tracer) Tracer {
+	t = orNoop(t)
+	if _, off := t.(NoopTracer); off {
+		return t
+	}
+	return &lockedTracer{t: t}
+}
+
+// lock takes the lock, or returns false when the tracer no longer records
+func (l *lockedTracer) lock() bool {
+	if atomic.LoadInt32(&l.done) == 1 {
+		return false
+	}
+	l.mu.Lock()
+	return true
+}
+
+func (l *lockedTracer) unlock() {
+	if lt, ok := l.t.(limitedTracer); ok && lt.full() {
+		atomic.StoreInt32(&l.done, 1)
+	}
+	l.mu.Unlock()
+}
+
+func (l *lockedTracer) OnVisit(id int) {
+	if !l.lock() {
+		return
+	}
+	defer l.unlock()
+	l.t.OnVisit(id)
+}
+
+func (l *lockedTracer) OnEnqueue(id int) {
+	if !l.lock() {
+		return
+	}
+	defer l.unlock()
+	l.t.OnEnqueue(id)
+}
+
+func (l *lockedTracer) OnDiscover(productID, parentID, partnerID int) {
+	if !l.lock() {
+		return
+	}
+	defer l.unlock()
+	l.t.OnDiscover(productID, parentID, partnerID)
+}
+
+func (l *lockedTracer) OnPrune(reason string, id, parentID, partnerID int) {
+	if !l.lock() {
+		return
+	}
+	defer l.unlock()
+	l.t.OnPrune(reason, id, parentID, partnerID)
+}
+
+func (l *lockedTracer) OnSolution(id int) {
+	if !l.lock() {
+		return
+	}
+	defer l.unlock()
+	l.t.OnSolution(id)
+}
+
+/*
+-------------------------------------------------------------------------
+Event tracers: capture, capped capture and streaming
+*/
+// eventTracer turns tracer calls into numbered StepEvents handed to emit
+type eventTracer struct {
+	g    IndexedGraph
+	seq  int
+	emit func(StepEvent)
+}
+
+func (e *eventTracer) event(kind string, id, parentID, partnerID int) {
+	ev := StepEvent{Seq: e.seq, Kind: kind, ID: id, Name: e.g.IDToName[id]}
+	if kind == EventDiscovered || kind == EventBacktrack {
+		ev.ParentID, ev.Parent = &parentID, e.g.IDToName[parentID]
+		ev.PartnerID, ev.Partner = &partnerID, e.g.IDToName[partnerID]
+	}
+	e.seq++
+	e.emit(ev)
+}
+
+func (e *eventTracer) OnVisit(id int)   { e.event(EventPopped, id, 0, 0) }
+func (e *eventTracer) OnEnqueue(id int) { e.event(EventPushed, id, 0, 0) }
+func (e *eventTracer) OnDiscover(productID, parentID, partnerID int) {
+	e.event(EventDiscovered, productID, parentID, partnerID)
+}
+func (e *eventTracer) OnPrune(reason string, id, parentID, partnerID int) {
+	e.event(reason, id, parentID, partnerID)
+}
+func (e *eventTracer) OnSolution(id int) { e.event(EventFound, id, 0, 0) }
+
+// CaptureTracer keeps the events of a search in memory, up to an optional limit
+type CaptureTracer struct {
+	eventTracer
+	limit  int
+	events []StepEvent
+}
+
+// NewCaptureTracer records every event of a search
+func NewCaptureTracer(g IndexedGraph) *CaptureTracer {
+	return NewCappedCaptureTracer(g, 0)
+}
+
+// NewCappedCaptureTracer records the first limit events and drops the rest (0 = no limit)
+func NewCappedCaptureTracer(g IndexedGraph, limit int) *CaptureTracer {
+	c := &CaptureTracer{limit: limit, events: []StepEvent{}}
+	c.eventTracer = eventTracer{g: g, emit: func(ev StepEvent) {
+		if !c.full() {
+			c.events = append(c.events, ev)
+		}
+	}}
+	return c
+}
+
+// Events returns the recorded events
+func (c *CaptureTracer) Events() []StepEvent { return c.events }
+
+// full reports whether further events are dropped
+func (c *CaptureTracer) full() bool { return c.limit > 0 && len(c.events) >= c.limit }
+
+// NewStreamTracer hands every event to onStep the moment it happens.
+// onStep is called synchronously, so a slow callback also slows the search down.
+func NewStreamTracer(g IndexedGraph, onStep func(StepEvent)) Tracer {
+	return &eventTracer{g: g, emit: onStep}
+}
+
+/*
+-------------------------------------------------------------------------
+Statistics
+*/
+// StatsTracer only counts what happened, by kind
+type StatsTracer struct {
+	Visits      int            `json:"visits"`
+	Enqueues    int            `json:"enqueues"`
+	Discoveries int            `json:"discoveries"`
+	Prunes      map[string]int `json:"prunes"` // By reason
+	Solutions   int            `json:"solutions"`
+}
+
+// NewStatsTracer returns an empty statistics tracer
+func NewStatsTracer() *StatsTracer {
+	return &StatsTracer{Prunes: make(map[string]int)}
+}
+
+func (s *StatsTracer) OnVisit(int)              { s.Visits++ }
+func (s *StatsTracer) OnEnqueue(int)            { s.Enqueues++ }
+func (s *StatsTracer) OnDiscover(int, int, int) { s.Discoveries++ }
+func (s *StatsTracer) OnPrune(reason string, _, _, _ int) {
+	s.Prunes[reason]++
+}
+func (s *StatsTracer) OnSolution(int) { s.Solutions++ }
+
+/*
+-------------------------------------------------------------------------
+Full-state snapshots (SearchStep)
+*/
+// StepTracer rebuilds the frontier, seen set and discovered recipes from the
+// tracer calls and stores a full SearchStep on every visit, as the
+// visualizer expects. For a DFS the frontier is the stack, and prunes
+// (cache hits, cycles, backtracking) get a step of their own. A snapshot copies the whole state, so use a limit for
+// large searches.
+type StepTracer struct {
+	g        IndexedGraph
+	targetID int
+	limit    int
+
+	queue      []int
+	seen       map[int]bool
+	discovered map[int]struct{ ParentID, PartnerID int }
+	stepNo     int // number of the last step taken
+	steps      []SearchStep
+}
+
+// NewStepTracer records up to limit snapshots of a search for targetID (0 = no limit)
+func NewStepTracer(g IndexedGraph, targetID, limit int) *StepTracer {
+	return &StepTracer{
+		g:          g,
+		targetID:   targetID,
+		limit:      limit,
+		seen:       make(map[int]bool),
+		discovered: make(map[int]struct{ ParentID, PartnerID int }),
+	}
+}
+
+// full reports whether no more snapshots will be taken
+func (s *StepTracer) full() bool {
+	return s.limit > 0 && len(s.steps) >= s.limit
+}
+
+// snapshot stores the current state, with the initial state as step 0
+func (s *StepTracer) snapshot(currentID, stepNumber int, found bool, kind string) {
+	if s.full() {
+		return
+	}
+	queue := append([]int{}, s.queue...)
+	names := make([]string, len(queue))
+	for i, id := range queue {
+		names[i] = s.g.IDToName[id]
+	}
+	s.steps = append(s.steps, SearchStep{
+		CurrentID:       currentID,
+		CurrentName:     s.g.IDToName[currentID],
+		QueueIDs:        queue,
+		QueueNames:      names,
+		SeenIDs:         mapKeysToSlice(s.seen),
+		SeenNames:       mapKeysToNameSlice(s.seen, s.g),
+		DiscoveredEdges: copyMap(s.discovered),
+		DiscoveredNames: prevIDsToNames(s.discovered, s.g),
+		StepNumber:      stepNumber,
+		FoundTarget:     found,
+		Kind:            kind,
+	})
+}
+
+func (s *StepTracer) OnVisit(id int) {
+	if s.full() {
+		return
+	}
+	if len(s.steps) == 0 {
+		s.snapshot(-1, 0, false, "") // initial state, before the first visit
+	}
+	// Parallel searches don't always visit in queue order
+	for i, q := range s.queue {
+		if q == id {
+			s.queue = append(s.queue[:i:i], s.queue[i+1:]...)
+			break
+		}
+	}
+	s.stepNo++
+	s.snapshot(id, s.stepNo, id == s.targetID, "")
+}
+
+func (s *StepTracer) OnEnqueue(id int) {
+	if s.full() {
+		return
+	}
+	s.queue = append(s.queue, id)
+	s.seen[id] = true
+}
+
+func (s *StepTracer) OnDiscover(productID, parentID, partnerID int) {
+	if s.full() {
+		return
+	}
+	s.seen[productID] = true
+	s.discovered[productID] = struct{ ParentID, PartnerID int }{parentID, partnerID}
+}
+
+func (s *StepTracer) OnPrune(reason string, id, _, _ int) {
+	if s.full() {
+		return
+	}
+	if len(s.steps) == 0 {
+		s.snapshot(-1, 0, false, "")
+	}
+	s.stepNo++
+	s.snapshot(id, s.stepNo, false, reason)
+}
+
+// OnSolution adds a final step for a target found by discovery rather than by a visit
+func (s *StepTracer) OnSolution(id int) {
+	if len(s.steps) == 0 {
+		s.snapshot(-1, 0, false, "")
+	}
+	if n := len(s.steps); n > 0 && s.steps[n-1].CurrentID == id && s.steps[n-1].FoundTarget {
+		return
+	}
+	s.snapshot(id, s.stepNo+1, true, "")
+}
+
+// Steps returns the recorded snapshots (at least the initial state)
+func (s *StepTracer) Steps() []SearchStep {
+	if len(s.steps) == 0 {
+		s.snapshot(-1, 0, false, "")
+	}
+	return s.steps
+}
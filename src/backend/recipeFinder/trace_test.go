@@ -0,0 +1,45 @@
+package recipeFinder
+
+import (
+	"sync"
+	"testing"
+)
+
+// countingTracer counts the calls that reach a capped tracer
+type countingTracer struct {
+	*CaptureTracer
+	calls int
+}
+
+func (c *countingTracer) OnVisit(id int) {
+	c.calls++
+	c.CaptureTracer.OnVisit(id)
+}
+
+// TestLockedTracerSkipsFullTracer checks that a capped tracer shared by
+// parallel workers keeps exactly its limit and is not called (nor locked)
+// any more once it is full
+func TestLockedTracerSkipsFullTracer(t *testing.T) {
+	const limit = 10
+	inner := &countingTracer{CaptureTracer: NewCappedCaptureTracer(loadSyntheticCatalog(), limit)}
+	tr := syncTracer(inner)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				tr.OnVisit(i % 4)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(inner.Events()); n != limit {
+		t.Errorf("recorded %d events, want %d", n, limit)
+	}
+	if inner.calls != limit {
+		t.Errorf("%d calls reached the full tracer, want %d", inner.calls, limit)
+	}
+}
@@ -1,6 +1,9 @@
 package recipeFinder
 
-import "log"
+import (
+	"context"
+	"log/slog"
+)
 
 // RecipeNode itu bentuk data JSON yang nanti kita kirim ke frontend.
 // - Name: nama elemen (misal "Brick")
@@ -11,7 +14,7 @@ type RecipeNode struct {
 	Children []*RecipeNode	`json:"children,omitempty"`
 }
 
-func BuildTrees(target string, pathPrev map[string][]RecipeStep) []*RecipeNode {
+func BuildTrees(ctx context.Context, target string, pathPrev map[string][]RecipeStep) []*RecipeNode {
 	var trees []*RecipeNode
 
 	for _, recipeStep := range pathPrev[target] {
@@ -29,7 +32,7 @@ func BuildTrees(target string, pathPrev map[string][]RecipeStep) []*RecipeNode {
 			}
 		}
 
-		tree := BuildTree(target, prev)
+		tree := BuildTree(ctx, target, prev)
 		trees = append(trees, tree)
 	}
 
@@ -47,11 +50,21 @@ func isBaseElement(name string) bool {
 
 const treeDepthLimit = 150
 
-func BuildTree(name string, prev ProductToIngredients) *RecipeNode {
-	return buildTreeRec(name, prev, make(map[string]bool), 0)
+// BuildTree turns a product → ingredients map into a tree rooted at name.
+// Elements missing from prev are filled in with a fallback BFS bound to ctx.
+func BuildTree(ctx context.Context, name string, prev ProductToIngredients) *RecipeNode {
+	return BuildTreeOn(ctx, GlobalIndexedGraph, name, prev)
+}
+
+// BuildTreeOn is BuildTree with the fallback BFS on graph, for callers that
+// searched a graph captured earlier instead of the active one
+func BuildTreeOn(ctx context.Context, graph IndexedGraph, name string, prev ProductToIngredients) *RecipeNode {
+	return buildTreeRec(ctx, graph, name, prev, make(map[string]bool), 0)
 }
 
 func buildTreeRec(
+	ctx context.Context,
+	graph IndexedGraph,
 	name string,
 	prev ProductToIngredients,
 	visited map[string]bool,
@@ -71,37 +84,36 @@ func buildTreeRec(
 	// 2. gunakan info resep dari prev jika ada -----------------------------
 	if step, ok := prev[name]; ok {
 		node.Children = []*RecipeNode{
-			buildTreeRec(step.Combo.A, prev, visited, depth+1),
-			buildTreeRec(step.Combo.B, prev, visited, depth+1),
+			buildTreeRec(ctx, graph, step.Combo.A, prev, visited, depth+1),
+			buildTreeRec(ctx, graph, step.Combo.B, prev, visited, depth+1),
 		}
 		return node
 	}
 
 	// 3. fallback sekali saja ----------------------------------------------
+	// Diagnostics are debug-level: multi-path queries trigger this a lot
 	if _, ok := prev[name]; !ok {
-		// Log whenever fallback is attempted
-		log.Printf("FALLBACK TRIGGERED for element %q at depth %d (visited elements: %v)", 
-			name, depth, getVisitedKeys(visited))
-		
-		if fb, _, nodesVisited := IndexedBFSBuild(name, GlobalIndexedGraph); len(fb) > 0 {
-			// Log fallback success details
-			log.Printf("FALLBACK SUCCESS for %q: found recipe via BFS (%d nodes visited)", 
-			name, nodesVisited)
-			
+		logger := Logger(ctx)
+		debug := logger.Enabled(ctx, slog.LevelDebug)
+		if debug {
+			logger.Debug("tree fallback triggered", "element", name, "depth", depth, "visited", getVisitedKeys(visited))
+		}
+
+		if fb, nodesVisited := IndexedBFSTrace(ctx, name, graph, nil); len(fb) > 0 {
 			if step, ok := fb[name]; ok {
-				// Log the exact recipe found
-				log.Printf("FALLBACK RECIPE for %q: %s + %s", 
-					name, step.Combo.A, step.Combo.B)
-					
+				if debug {
+					logger.Debug("tree fallback recipe", "element", name, "a", step.Combo.A, "b", step.Combo.B,
+						"nodes_visited", nodesVisited)
+				}
 				node.Children = []*RecipeNode{
-					buildTreeRec(step.Combo.A, fb, visited, depth+1),
-					buildTreeRec(step.Combo.B, fb, visited, depth+1),
+					buildTreeRec(ctx, graph, step.Combo.A, fb, visited, depth+1),
+					buildTreeRec(ctx, graph, step.Combo.B, fb, visited, depth+1),
 				}
 			} else {
-				log.Printf("FALLBACK ERROR: BFS returned recipes but none for %q?!", name)
+				logger.Warn("tree fallback: BFS returned recipes but none for the element", "element", name)
 			}
-		} else {
-			log.Printf("FALLBACK FAILED for %q: BFS found no recipes", name)
+		} else if debug {
+			logger.Debug("tree fallback failed: BFS found no recipe", "element", name)
 		}
 	}
 	return node
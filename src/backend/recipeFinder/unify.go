@@ -1,8 +1,11 @@
 package recipeFinder
 
+import "context"
+
 
 // UnifiedRecipeTree builds a complete tree showing all ways to make an element
-func UnifiedRecipeTree(targetName string, graph IndexedGraph) *RecipeNode {
+// Branches still unexplored when ctx is done are left as leaves
+func UnifiedRecipeTree(ctx context.Context, targetName string, graph IndexedGraph) *RecipeNode {
     // Create reverse mapping: product → all recipes that make it
     reverseGraph := buildReverseGraph(graph)
     
@@ -10,17 +13,17 @@ func UnifiedRecipeTree(targetName string, graph IndexedGraph) *RecipeNode {
     visited := make(map[string]bool)
     
     // Build the complete tree recursively
-    return buildUnifiedTree(targetName, reverseGraph, graph, visited, 0)
+    return buildUnifiedTree(ctx, targetName, reverseGraph, graph, visited, 0)
 }
 
 // Recursive helper to build the tree
-func buildUnifiedTree(elementName string, reverseGraph map[int][]struct{InputA, InputB int}, 
+func buildUnifiedTree(ctx context.Context, elementName string, reverseGraph map[int][]struct{InputA, InputB int}, 
                        graph IndexedGraph, visited map[string]bool, depth int) *RecipeNode {
     // Create node for this element
     node := &RecipeNode{Name: elementName}
     
-    // Base case: stop at base elements or max depth
-    if isBaseElement(elementName) || depth > 30 {
+    // Base case: stop at base elements, max depth or cancellation
+    if isBaseElement(elementName) || depth > 30 || ctx.Err() != nil {
         return node
     }
     
@@ -50,8 +53,8 @@ func buildUnifiedTree(elementName string, reverseGraph map[int][]struct{InputA,
         ingredientB := graph.IDToName[recipe.InputB]
         
         // Recursively build trees for both ingredients
-        childA := buildUnifiedTree(ingredientA, reverseGraph, graph, visited, depth+1)
-        childB := buildUnifiedTree(ingredientB, reverseGraph, graph, visited, depth+1)
+        childA := buildUnifiedTree(ctx, ingredientA, reverseGraph, graph, visited, depth+1)
+        childB := buildUnifiedTree(ctx, ingredientB, reverseGraph, graph, visited, depth+1)
         
         // Create a combiner node to represent this specific recipe
         combiner := &RecipeNode{
@@ -0,0 +1,75 @@
+// backend/refresh.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// refreshLoop re-scrapes the wiki every interval until the server shuts down.
+// A failed or suspicious refresh is logged and the current catalog stays in place.
+func refreshLoop(interval time.Duration) {
+	slog.Info("scheduled refresh enabled", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-serverCtx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := refreshCatalog(); err != nil {
+			slog.Warn("scheduled refresh skipped", "error", err)
+		}
+	}
+}
+
+// refreshCatalog scrapes a new catalog and only installs it when it passes
+// validation and the diff against the active catalog stays within the
+// -refresh-max-removed / -refresh-max-recipes-removed limits.
+func refreshCatalog() error {
+	scrapeMu.Lock()
+	defer scrapeMu.Unlock()
+
+	t0 := time.Now()
+	catalog, report, err := recipeFinder.ScrapeAll(serverCtx, *downloadSVGs, scrapeLimits())
+	logScrapeReport(report)
+	observeScrape("refresh", t0, err)
+	if err != nil {
+		return fmt.Errorf("scrape failed: %w", err)
+	}
+	if err := recipeFinder.ValidateCatalog(catalog); err != nil {
+		refreshRejected.Inc("validation")
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	catalogMu.RLock()
+	current := recipeFinder.GlobalCatalog
+	catalogMu.RUnlock()
+
+	diff := recipeFinder.DiffCatalogs(current, catalog)
+	if ratio := diff.RemovedElementRatio(); ratio > *refreshMaxRemoved {
+		refreshRejected.Inc("elements_removed")
+		return fmt.Errorf("%d of %d elements removed (%.1f%%, limit %.1f%%)",
+			len(diff.RemovedElements), diff.OldElements, ratio*100, *refreshMaxRemoved*100)
+	}
+	if ratio := diff.RemovedRecipeRatio(); ratio > *refreshMaxRecipesRemoved {
+		refreshRejected.Inc("recipes_removed")
+		return fmt.Errorf("%d of %d recipes removed (%.1f%%, limit %.1f%%)",
+			diff.RemovedRecipes, diff.OldRecipes, ratio*100, *refreshMaxRecipesRemoved*100)
+	}
+
+	if err := installCatalog(catalog); err != nil {
+		return err
+	}
+
+	slog.Info("scheduled refresh applied",
+		"elements_added", len(diff.AddedElements), "elements_removed", len(diff.RemovedElements),
+		"recipes_added", diff.AddedRecipes, "recipes_removed", diff.RemovedRecipes)
+	return nil
+}
@@ -0,0 +1,114 @@
+// backend/server.go
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// envPrefix names the environment variables that set flags: -search-timeout
+// can also be given as BACKEND_SEARCH_TIMEOUT. Command line flags win.
+const envPrefix = "BACKEND_"
+
+// serverCtx is cancelled as soon as the server starts shutting down (SIGINT /
+// SIGTERM). Work that outlives a single request — scrapes, live search
+// sessions, the warm-up — derives from it; requests themselves are drained.
+var serverCtx = context.Background()
+
+// envName returns the environment variable of a flag
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets flag defaults from the environment. GO_ENV=production
+// switches to JSON logs; the BACKEND_* variables override single flags.
+// It must run before flag.Parse so the command line still has the last word.
+func applyEnv() error {
+	if os.Getenv("GO_ENV") == "production" {
+		flag.Set("log-format", "json")
+	}
+
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		v, ok := os.LookupEnv(envName(f.Name))
+		if !ok || err != nil {
+			return
+		}
+		if setErr := f.Value.Set(v); setErr != nil {
+			err = fmt.Errorf("invalid %s=%q: %w", envName(f.Name), v, setErr)
+		}
+	})
+	return err
+}
+
+// usage prints the flags together with their environment variables
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprintf(out, "\nEvery flag can also be set with an environment variable, e.g. -search-timeout as %s.\n",
+		envName("search-timeout"))
+	fmt.Fprintln(out, "GO_ENV=production defaults to -log-format json.")
+}
+
+// newServer builds the HTTP server from the -addr / timeout / header flags
+func newServer(h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              *addr,
+		Handler:           h,
+		ReadTimeout:       *readTimeout,
+		ReadHeaderTimeout: *readHeaderTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       *idleTimeout,
+		MaxHeaderBytes:    *maxHeaderBytes,
+	}
+}
+
+// serve runs srv until ctx is cancelled, then stops accepting connections
+// and gives in-flight requests -shutdown-timeout to finish before closing them
+func serve(ctx context.Context, srv *http.Server) error {
+	errs := make(chan error, 1)
+	go func() { errs <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down, draining in-flight requests", "timeout", *shutdownTimeout)
+	drainCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(drainCtx); err != nil {
+		slog.Warn("shutdown timeout exceeded, closing remaining connections", "error", err)
+		srv.Close()
+	}
+	slog.Info("server stopped")
+	return nil
+}
+
+// extendDeadlines gives a request that runs for up to budget (a search) that
+// much time on top of the server's write timeout. The request has been read
+// by then, and an expiring read deadline would cancel its context
+// mid-search, so the read deadline is lifted.
+func extendDeadlines(w http.ResponseWriter, budget time.Duration) {
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	if *writeTimeout > 0 {
+		rc.SetWriteDeadline(time.Now().Add(*writeTimeout + budget))
+	}
+}
+
+// clearDeadlines lifts the server timeouts for a connection that stays open
+// for as long as the client wants (a WebSocket session)
+func clearDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+}
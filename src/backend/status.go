@@ -0,0 +1,130 @@
+// backend/status.go
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// buildVersion is the release version, set at build time with
+// go build -ldflags "-X main.buildVersion=v1.2.3"
+var buildVersion = "dev"
+
+// startedAt is when the process started, for the uptime
+var startedAt = time.Now()
+
+// StatusResponse is the body of /api/status
+type StatusResponse struct {
+	Ready         bool            `json:"ready"`              // Same as /ready
+	Snapshot      *SnapshotStatus `json:"snapshot,omitempty"` // Active catalog, nil before one is loaded
+	StartedAt     time.Time       `json:"started_at"`
+	UptimeSeconds float64         `json:"uptime_seconds"`
+	Build         BuildInfo       `json:"build"`
+	Warmup        warmupStatus    `json:"warmup"` // Progress of the recipe table warm-up
+}
+
+// SnapshotStatus describes the active catalog snapshot
+type SnapshotStatus struct {
+	Dataset   string     `json:"dataset"`
+	Version   string     `json:"version"`
+	Tiers     int        `json:"tiers"`
+	Elements  int        `json:"elements"`
+	Recipes   int        `json:"recipes"`
+	ScrapedAt *time.Time `json:"scraped_at,omitempty"` // Unknown if recipe.json has no mtime
+}
+
+// BuildInfo identifies the running binary
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	Revision  string `json:"revision,omitempty"` // VCS commit, when built from a checkout
+	Modified  bool   `json:"modified,omitempty"` // Built with uncommitted changes
+	BuildTime string `json:"build_time,omitempty"`
+}
+
+// buildInfo reads the version stamped into the binary by the Go toolchain
+func buildInfo() BuildInfo {
+	info := BuildInfo{Version: buildVersion, GoVersion: runtime.Version()}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		case "vcs.time":
+			info.BuildTime = s.Value
+		}
+	}
+	return info
+}
+
+// catalogReady reports whether a catalog is loaded and its indexed graph built.
+// The caller must hold catalogMu.
+func catalogReady() bool {
+	return catalogVersion != "" && len(recipeFinder.GlobalIndexedGraph.NameToID) > 0
+}
+
+// handleHealth serves /health — liveness: answers as long as the server runs
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleReady serves /ready — 200 once a catalog is loaded and searchable, 503 before
+func handleReady(w http.ResponseWriter, r *http.Request) {
+	catalogMu.RLock()
+	ready, version := catalogReady(), catalogVersion
+	catalogMu.RUnlock()
+
+	if !ready {
+		writeError(w, http.StatusServiceUnavailable, codeNotReady,
+			"no catalog loaded (run with -scrape or POST /api/scrape)", nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ready", "version": version})
+}
+
+// handleStatus serves /api/status — the active snapshot, uptime, build and warm-up state
+func handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	resp := StatusResponse{
+		StartedAt:     startedAt,
+		UptimeSeconds: time.Since(startedAt).Seconds(),
+		Build:         buildInfo(),
+		Warmup:        currentWarmup(),
+	}
+
+	catalogMu.RLock()
+	resp.Ready = catalogReady()
+	if resp.Ready {
+		snap := &SnapshotStatus{
+			Dataset: catalogDataset,
+			Version: catalogVersion,
+			Tiers:   len(recipeFinder.GlobalCatalog.Tiers),
+		}
+		snap.Elements, snap.Recipes = recipeFinder.CountElements(recipeFinder.GlobalCatalog)
+		if !catalogScrapedAt.IsZero() {
+			scrapedAt := catalogScrapedAt
+			snap.ScrapedAt = &scrapedAt
+		}
+		resp.Snapshot = snap
+	}
+	catalogMu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,193 @@
+// backend/stream.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// streamProgressInterval is how often a progress event is pushed while searching
+const streamProgressInterval = 250 * time.Millisecond
+
+// handleFindStream serves /api/v1/find/stream — same parameters as /find, but
+// every recipe tree is pushed as a Server-Sent Event the moment it is found:
+//
+//	event: tree      {"index": 0, "tree": {...}}                  StreamTreeEvent
+//	event: progress  {"trees": 3, "elapsed_ms": 250}               StreamProgressEvent
+//	event: done      {"algorithm": "bfs", "trees": 5, ...}         StreamDoneEvent
+//	event: error     {"error": {...}}
+//
+// Closing the connection stops the search.
+func handleFindStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, codeInternal, "streaming is not supported by this server", nil)
+		return
+	}
+
+	// The search runs on the graph captured with the parameters, so a slow
+	// client doesn't hold catalogMu (and with it scrapes and reloads) for the
+	// whole stream
+	catalogMu.RLock()
+	p, ok := parseFindParams(w, r)
+	catalogMu.RUnlock()
+	if !ok {
+		return // parameter errors are sent as regular JSON before the stream starts
+	}
+	p.Steps = "none" // the stream sends no search steps
+
+	ctx, cancel := context.WithTimeout(r.Context(), p.Timeout)
+	defer cancel()
+	extendDeadlines(w, p.Timeout) // the stream stays open for the whole search
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // disable proxy buffering
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	trees := make(chan *recipeFinder.RecipeNode)
+	done := make(chan FindResult, 1)
+	go func() { done <- streamFind(ctx, p, trees) }()
+
+	ticker := time.NewTicker(streamProgressInterval)
+	defer ticker.Stop()
+
+	t0 := time.Now()
+	sent := 0
+	for {
+		select {
+		case tree := <-trees:
+			writeEvent(w, "tree", StreamTreeEvent{Index: sent, Tree: tree})
+			sent++
+			flusher.Flush()
+
+		case <-ticker.C:
+			writeEvent(w, "progress", StreamProgressEvent{Trees: sent, ElapsedMs: time.Since(t0).Milliseconds()})
+			flusher.Flush()
+
+		case res := <-done:
+			if r.Context().Err() != nil {
+				return // client went away, nobody to tell
+			}
+
+			timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+			if !res.Precomputed {
+				observeSearch(p, res.NodesVisited, sent, timedOut)
+			}
+			if sent == 0 && !hasRecipe(p.Target, res.Trees...) {
+				// The same error, details included, /find answers with
+				_, apiErr := checkFindResult(p, &res, timedOut)
+				writeEvent(w, "error", errorEnvelope{Error: *apiErr})
+				flusher.Flush()
+				return
+			}
+
+			final := StreamDoneEvent{
+				Algorithm:    res.Algorithm,
+				Trees:        sent,
+				NodesVisited: res.NodesVisited,
+				DurationMs:   res.DurationMs,
+			}
+			if timedOut {
+				final.Truncated = true
+				final.Warning = &APIError{
+					Code:    codeResultTruncated,
+					Message: fmt.Sprintf("time budget of %dms exhausted, results are partial", p.Timeout.Milliseconds()),
+				}
+			}
+			writeEvent(w, "done", final)
+			flusher.Flush()
+			return
+		}
+	}
+}
+
+// streamFind runs the search and sends every new (deduplicated) tree to out
+// as soon as it is found. Multi-recipe BFS/DFS stream path by path, the other
+// modes send their result once the search has finished.
+// The search stops after p.MaxPaths trees or when ctx is done.
+func streamFind(ctx context.Context, p findParams, out chan<- *recipeFinder.RecipeNode) FindResult {
+	searchCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	seen := make(map[string]bool)
+	emit := func(tree *recipeFinder.RecipeNode) {
+		sig := recipeFinder.TreeSignature(tree)
+		if seen[sig] || len(seen) >= p.MaxPaths {
+			return
+		}
+		seen[sig] = true
+		select {
+		case out <- tree:
+		case <-ctx.Done():
+			return
+		}
+		if len(seen) == p.MaxPaths {
+			stop() // enough trees, no need to keep searching
+		}
+	}
+
+	res := FindResult{Target: p.Target, Algorithm: p.Algorithm, Multi: p.Multi}
+	t0 := time.Now()
+	g := p.Graph
+	opts := searchOptions(p)
+
+	if !p.Multi || p.Algorithm == "bidirectional" {
+		// Single recipe: nothing to stream until the search is done
+		res = runFind(searchCtx, p)
+		if len(res.Trees) > 0 && hasRecipe(p.Target, res.Trees[0]) {
+			emit(res.Trees[0])
+		}
+		res.DurationMs = float64(time.Since(t0).Microseconds()) / 1000.0
+		return res
+	}
+
+	// The search reports raw paths from inside its own locks, so turning them
+	// into trees (which may run fallback searches) happens here instead.
+	// The buffer fits every path the search can report, so it never blocks.
+	pending := make(chan func() []*recipeFinder.RecipeNode, p.MaxPaths*5+1)
+	go func() {
+		defer close(pending)
+		if p.Algorithm == "dfs" {
+			_, res.NodesVisited = recipeFinder.RangeDFSPathsStream(searchCtx, p.Target, p.MaxPaths*2, g, opts,
+				func(step recipeFinder.RecipeStep) {
+					pending <- func() []*recipeFinder.RecipeNode {
+						return stepsToTrees(ctx, g, p.Target, []recipeFinder.RecipeStep{step})
+					}
+				}, nil)
+			return
+		}
+		_, res.NodesVisited = recipeFinder.ReversedMultiPathBFSParallelStream(searchCtx, p.Target, g, p.MaxPaths*5, opts,
+			func(path recipeFinder.ProductToIngredients) {
+				pending <- func() []*recipeFinder.RecipeNode {
+					return []*recipeFinder.RecipeNode{recipeFinder.BuildTreeOn(ctx, g, p.Target, path)}
+				}
+			}, nil)
+	}()
+
+	for build := range pending {
+		if ctx.Err() != nil {
+			continue // drain without building
+		}
+		for _, tree := range build() {
+			emit(tree)
+		}
+	}
+
+	res.DurationMs = float64(time.Since(t0).Microseconds()) / 1000.0
+	return res
+}
+
+// writeEvent writes one Server-Sent Event with a JSON payload
+func writeEvent(w http.ResponseWriter, event string, data interface{}) {
+	raw, _ := json.Marshal(data)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, raw)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sseEvent is one Server-Sent Event of a response
+type sseEvent struct {
+	name string
+	data json.RawMessage
+}
+
+// readStream runs handleFindStream and splits its body into events
+func readStream(t *testing.T, query string) []sseEvent {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleFindStream(w, httptest.NewRequest(http.MethodGet, "/api/v1/find/stream?"+query, nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("%s: status %d, content type %q: %s", query, w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+
+	var events []sseEvent
+	var ev sseEvent
+	sc := bufio.NewScanner(w.Body)
+	for sc.Scan() {
+		switch line := sc.Text(); {
+		case strings.HasPrefix(line, "event: "):
+			ev.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.data = json.RawMessage(strings.TrimPrefix(line, "data: "))
+		case line == "":
+			events = append(events, ev)
+			ev = sseEvent{}
+		}
+	}
+	return events
+}
+
+func TestFindStreamSendsTreesThenDone(t *testing.T) {
+	useTestCatalog(t)
+
+	events := readStream(t, "target=brick&maxPaths=3")
+	var trees int
+	for _, ev := range events[:len(events)-1] {
+		switch ev.name {
+		case "tree":
+			var tree StreamTreeEvent
+			if err := json.Unmarshal(ev.data, &tree); err != nil || tree.Index != trees || tree.Tree.Name != "Brick" {
+				t.Errorf("tree event %s: %v", ev.data, err)
+			}
+			trees++
+		case "progress":
+		default:
+			t.Errorf("unexpected %s event before the end: %s", ev.name, ev.data)
+		}
+	}
+	last := events[len(events)-1]
+	var done StreamDoneEvent
+	if err := json.Unmarshal(last.data, &done); last.name != "done" || err != nil || done.Trees != trees || trees == 0 {
+		t.Errorf("last event %s %s, want done after %d trees", last.name, last.data, trees)
+	}
+}
+
+func TestFindStreamErrorHasDetails(t *testing.T) {
+	useTestCatalog(t)
+
+	for _, algorithm := range []string{"bfs", "dfs"} {
+		events := readStream(t, "target=Ghost&algorithm="+algorithm)
+		last := events[len(events)-1]
+		var env struct {
+			Error struct {
+				Code    string            `json:"code"`
+				Details map[string]string `json:"details"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(last.data, &env); last.name != "error" || err != nil {
+			t.Fatalf("%s: last event %s %s, want an error", algorithm, last.name, last.data)
+		}
+		// The same envelope /find answers with, details included
+		if env.Error.Code != codeUnreachableElement || env.Error.Details["target"] != "Ghost" || env.Error.Details["algorithm"] != algorithm {
+			t.Errorf("%s: error %s", algorithm, last.data)
+		}
+	}
+}
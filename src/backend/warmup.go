@@ -0,0 +1,180 @@
+// backend/warmup.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/wiwekaputera/Tubes2_SemogaGaMasukUGD/backend/recipeFinder"
+)
+
+// tableFile stores the precomputed recipe table next to recipe.json
+const tableFile = jsonDir + "/recipe_table.json"
+
+// Warm-up states reported on /api/status
+const (
+	warmupDisabled = "disabled" // -warmup=false
+	warmupIdle     = "idle"     // no catalog loaded yet
+	warmupRunning  = "running"  // computing the table
+	warmupReady    = "ready"    // table available for lookups
+	warmupFailed   = "failed"   // computing or loading failed, searches run live
+)
+
+// warmupStatus is the progress of the recipe table warm-up
+type warmupStatus struct {
+	State      string     `json:"state"`             // One of the warmup* constants
+	Version    string     `json:"version,omitempty"` // Snapshot the table is (being) computed for
+	Source     string     `json:"source,omitempty"`  // "computed" or "disk"
+	Done       int        `json:"done"`              // Elements finished
+	Total      int        `json:"total"`             // Elements in the snapshot
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+var (
+	// warmupMu guards the fields below. Lock order: catalogMu before warmupMu.
+	warmupMu     sync.Mutex
+	warmup       = warmupStatus{State: warmupIdle}
+	recipeTable  *recipeFinder.RecipeTable // table of the last finished warm-up
+	cancelWarmup context.CancelFunc        // stops the running warm-up
+)
+
+// startWarmup replaces the recipe table with one for the given snapshot,
+// loaded from tableFile when it matches, computed in the background otherwise.
+// The caller holds catalogMu for writing (the snapshot was just activated).
+func startWarmup(version string, g recipeFinder.IndexedGraph) {
+	warmupMu.Lock()
+	defer warmupMu.Unlock()
+
+	if cancelWarmup != nil {
+		cancelWarmup() // the previous snapshot's table is no longer needed
+	}
+	recipeTable = nil
+	if !*warmupEnabled {
+		warmup = warmupStatus{State: warmupDisabled}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(serverCtx) // also stops on shutdown
+	cancelWarmup = cancel
+	now := time.Now()
+	warmup = warmupStatus{State: warmupRunning, Version: version, Total: len(g.NameToID), StartedAt: &now}
+
+	go runWarmup(ctx, version, g)
+}
+
+// runWarmup loads or computes the recipe table and installs it
+func runWarmup(ctx context.Context, version string, g recipeFinder.IndexedGraph) {
+	if table, err := loadRecipeTable(version); err == nil {
+		finishWarmup(ctx, table, "disk", nil)
+		return
+	} else if !os.IsNotExist(err) {
+		slog.Info("recomputing recipe table", "reason", err)
+	}
+
+	slog.Info("warm-up: computing recipe table", "elements", len(g.NameToID))
+	table, err := recipeFinder.BuildRecipeTable(ctx, g, version, *warmupWorkers, catalogMu.RLocker(),
+		func(done, total int) {
+			warmupMu.Lock()
+			defer warmupMu.Unlock()
+			if warmup.Version == version && done > warmup.Done {
+				warmup.Done = done
+			}
+		})
+	if err != nil {
+		finishWarmup(ctx, nil, "computed", err)
+		return
+	}
+
+	if err := saveRecipeTable(table); err != nil {
+		slog.Warn("warm-up: saving recipe table failed", "error", err)
+	}
+	finishWarmup(ctx, table, "computed", nil)
+}
+
+// finishWarmup installs a table (or records the error) unless the warm-up
+// was superseded by a newer snapshot in the meantime
+func finishWarmup(ctx context.Context, table *recipeFinder.RecipeTable, source string, err error) {
+	warmupMu.Lock()
+	defer warmupMu.Unlock()
+
+	if ctx.Err() != nil {
+		return // cancelled: startWarmup already reset the status
+	}
+	now := time.Now()
+	warmup.Source = source
+	warmup.FinishedAt = &now
+	if err != nil {
+		warmup.State = warmupFailed
+		warmup.Error = err.Error()
+		slog.Error("warm-up failed", "error", err)
+		return
+	}
+	warmup.State = warmupReady
+	warmup.Done = len(table.Entries)
+	recipeTable = table
+	slog.Info("warm-up: recipe table ready", "elements", len(table.Entries), "source", source,
+		"duration", now.Sub(*warmup.StartedAt).Round(time.Millisecond))
+}
+
+// lookupRecipeTable returns the precomputed entry of an element, if the
+// table of snapshot version is ready
+func lookupRecipeTable(version, name string) (recipeFinder.RecipeTableEntry, bool) {
+	warmupMu.Lock()
+	defer warmupMu.Unlock()
+
+	if recipeTable == nil || recipeTable.Version != version {
+		return recipeFinder.RecipeTableEntry{}, false
+	}
+	return recipeTable.Lookup(name)
+}
+
+// currentWarmup returns a copy of the warm-up progress
+func currentWarmup() warmupStatus {
+	warmupMu.Lock()
+	defer warmupMu.Unlock()
+	if !*warmupEnabled {
+		return warmupStatus{State: warmupDisabled}
+	}
+	return warmup
+}
+
+// loadRecipeTable reads tableFile, which must belong to the given snapshot
+func loadRecipeTable(version string) (*recipeFinder.RecipeTable, error) {
+	raw, err := os.ReadFile(tableFile)
+	if err != nil {
+		return nil, err
+	}
+	var table recipeFinder.RecipeTable
+	if err := json.Unmarshal(raw, &table); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", tableFile, err)
+	}
+	if table.Version != version {
+		return nil, fmt.Errorf("%s is for snapshot %s, not %s", tableFile, table.Version, version)
+	}
+	return &table, nil
+}
+
+// saveRecipeTable writes the table to tableFile. It goes through a temporary
+// file so a crash never leaves a half-written table behind.
+func saveRecipeTable(table *recipeFinder.RecipeTable) error {
+	raw, err := json.Marshal(table)
+	if err != nil {
+		return fmt.Errorf("encode recipe table: %w", err)
+	}
+	os.MkdirAll(jsonDir, 0o755) // ensure directory exists
+	tmp := tableFile + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, tableFile); err != nil {
+		return fmt.Errorf("write %s: %w", tableFile, err)
+	}
+	return nil
+}
@@ -108,7 +108,9 @@ function LiveSearchVisualizer({ searchSteps, targetElement }) {
           <strong>Step:</strong> {step.step} of {searchSteps.length - 1}
         </div>
         <div>
+          {/* kind: langkah DFS yang memangkas cabang (cache_hit, cycle, backtrack) */}
           <strong>Current Element:</strong> {step.current || "None"}
+          {step.kind && ` (${step.kind})`}
         </div>
         <div>
           <strong>Queue Size:</strong> {step.queue?.length || 0} elements
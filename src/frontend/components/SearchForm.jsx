@@ -1,5 +1,7 @@
 "use client";
 
+import { useState, useEffect } from "react";
+
 function SearchForm({
   algorithm,
   setAlgorithm,
@@ -13,6 +15,29 @@ function SearchForm({
   isLoading,
   availableElements,
 }) {
+  const [suggestions, setSuggestions] = useState([]);
+
+  // Ambil saran elemen dari backend setiap kali input berubah
+  useEffect(() => {
+    if (!targetElement) {
+      setSuggestions([]);
+      return;
+    }
+    const controller = new AbortController();
+    const timer = setTimeout(() => {
+      fetch(`/api/elements?q=${encodeURIComponent(targetElement)}&limit=8`, {
+        signal: controller.signal,
+      })
+        .then((res) => (res.ok ? res.json() : { elements: [] }))
+        .then((data) => setSuggestions(data.elements || []))
+        .catch(() => {});
+    }, 150);
+    return () => {
+      clearTimeout(timer);
+      controller.abort();
+    };
+  }, [targetElement]);
+
   return (
     <div className="search-form">
       <div className="form-group">
@@ -76,7 +101,16 @@ function SearchForm({
             onChange={(e) => setTargetElement(e.target.value)}
             placeholder="Masukkan nama elemen"
             disabled={isLoading}
+            list="elementSuggestions"
+            autoComplete="off"
           />
+          <datalist id="elementSuggestions">
+            {suggestions.map((el) => (
+              <option key={el.name} value={el.name}>
+                {`Tier ${el.tier} · ${el.recipe_count} recipe(s)`}
+              </option>
+            ))}
+          </datalist>
         </div>
       </div>
 
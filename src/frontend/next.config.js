@@ -3,11 +3,25 @@
 const nextConfig = {
   async rewrites() {
     const backendUrl = process.env.BACKEND_URL || 'http://localhost:8080'; // Default to localhost for local development
+    // The Next server sets X-Forwarded-For (the browser's address) on proxied
+    // requests; the backend rate limits by it when run with -trust-forwarded-for
     return [
+      {
+        source: '/api/v1/:path*',
+        destination: `${backendUrl}/api/v1/:path*`
+      },
       {
         source: '/api/elements',
         destination: `${backendUrl}/api/elements`
       },
+      {
+        source: '/api/element/:name',
+        destination: `${backendUrl}/api/element/:name`
+      },
+      {
+        source: '/api/find/stream',
+        destination: `${backendUrl}/api/find/stream`
+      },
       {
         source: '/api/find',
         destination: `${backendUrl}/api/find`
@@ -1,15 +1,26 @@
 // pages/atlas/[element].js
+import { useState, useEffect } from "react";
+import RecipeAtlas from "../components/RecipeAtlas";
+
 export default function AtlasPage({ params }) {
   const { element } = params;
   const [recipes, setRecipes] = useState([]);
 
   useEffect(() => {
-    // Fetch recipes for this element
-    fetch(`/api/find?target=${element}&multi=true&maxPaths=100`)
-      .then((res) => res.json())
-      .then((data) => {
-        setRecipes(data.tree || []);
-      });
+    // Stream recipes for this element, showing each tree as soon as it is found
+    setRecipes([]);
+    const source = new EventSource(
+      `/api/find/stream?target=${encodeURIComponent(element)}&multi=true&maxPaths=100`
+    );
+    source.addEventListener("tree", (e) => {
+      const { tree } = JSON.parse(e.data);
+      setRecipes((prev) => [...prev, tree]);
+    });
+    source.addEventListener("done", () => source.close());
+    source.addEventListener("error", () => source.close());
+
+    // Leaving the page closes the stream, which stops the search on the server
+    return () => source.close();
   }, [element]);
 
   return <RecipeAtlas recipes={recipes} elementName={element} />;
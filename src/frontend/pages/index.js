@@ -37,6 +37,8 @@ export default function Index() {
   const [error, setError] = useState(null);
   const [response, setResponse] = useState(null);
   const [viewMode, setViewMode] = useState("result");
+  const [searchUrl, setSearchUrl] = useState("");
+  const [searchSteps, setSearchSteps] = useState(null);
   const [showAtlas, setShowAtlas] = useState(false);
 
   const handleSearch = async () => {
@@ -46,18 +48,34 @@ export default function Index() {
     setError(null);
     setResponse(null);
     setResults(null);
+    setSearchSteps(null);
+    setSearchUrl("");
     setSubmittedTarget(targetElement);
 
     try {
       const url = `/api/find?target=${encodeURIComponent(targetElement)}&multi=${
         multiMode ? "true" : "false"
       }&maxPaths=${maxRecipes}&algorithm=${algorithm}`;
-      const res = await fetch(url);
+      // Langkah pencarian hanya diminta kalau tab Process sedang dibuka
+      const withSteps = viewMode === "process";
+      const res = await fetch(`${url}&steps=${withSteps ? "full" : "none"}`);
+      setSearchUrl(url);
 
-      if (!res.ok) throw new Error(`HTTP ${res.status}`);
+      if (!res.ok) {
+        const body = await res.json().catch(() => null);
+        const apiError = body && body.error;
+        const suggestions = (apiError && apiError.details && apiError.details.suggestions) || [];
+        if (suggestions.length > 0) {
+          throw new Error(`${apiError.message}. Did you mean: ${suggestions.join(", ")}?`);
+        }
+        throw new Error(apiError ? apiError.message : `HTTP ${res.status}`);
+      }
 
       const data = await res.json();
       setResponse(data);
+      if (withSteps) {
+        setSearchSteps(data.search_steps || []);
+      }
 
       setSearchStats({
         time: data.duration_ms,
@@ -80,6 +98,20 @@ export default function Index() {
     }
   };
 
+  // Pencarian tanpa langkah (steps=none) bisa dijawab dari tabel resep backend;
+  // langkahnya baru diminta (steps=full) saat tab Process dibuka setelahnya
+  useEffect(() => {
+    if (viewMode !== "process" || searchSteps || !searchUrl) return;
+    let stale = false;
+    fetch(`${searchUrl}&steps=full`)
+      .then((res) => (res.ok ? res.json() : null))
+      .then((data) => !stale && setSearchSteps((data && data.search_steps) || []))
+      .catch(() => !stale && setSearchSteps([]));
+    return () => {
+      stale = true;
+    };
+  }, [viewMode, searchUrl, searchSteps]);
+
   const handleScrape = async () => {
     if (!confirm("This will scrape all recipes from the wiki. Continue?")) {
       return;
@@ -87,23 +119,45 @@ export default function Index() {
 
     try {
       setIsLoading(true);
-      const response = await fetch("/api/scrape", {
-        method: "POST",
-      });
+      // Scraping needs the backend's admin token; ask for it once per session
+      const scrape = () =>
+        fetch("/api/scrape", {
+          method: "POST",
+          headers: sessionStorage.getItem("adminToken")
+            ? { Authorization: `Bearer ${sessionStorage.getItem("adminToken")}` }
+            : {},
+        });
+      let response = await scrape();
+      if (response.status === 401) {
+        const token = prompt("Admin token:");
+        if (!token) {
+          return;
+        }
+        sessionStorage.setItem("adminToken", token);
+        response = await scrape();
+        if (response.status === 401) {
+          sessionStorage.removeItem("adminToken");
+        }
+      }
 
       if (!response.ok) {
-        throw new Error(`HTTP error ${response.status}`);
+        const body = await response.json().catch(() => null);
+        throw new Error(body && body.error ? body.error.message : `HTTP error ${response.status}`);
       }
 
       const data = await response.json();
-      alert(`Scraping completed! ${data.message}`);
+      const warningCount = data.warnings ? data.warnings.length : 0;
+      alert(
+        `Scraping completed! ${data.message}` +
+          (warningCount > 0 ? ` (${warningCount} parse warnings)` : "")
+      );
 
       // Refresh available elements to get the new data
-      //   fetch("/api/elements")
-      //     .then((res) => res.json())
-      //     .then((data) => {
-      //       setAvailableElements(data.elements || []);
-      //     });
+      fetch("/api/elements")
+        .then((res) => res.json())
+        .then((data) => {
+          setAvailableElements(data.elements || []);
+        });
     } catch (error) {
       alert("Error scraping data: " + error.message);
       console.error("Scrape error:", error);
@@ -253,7 +307,7 @@ export default function Index() {
                   <RecipeAtlas recipes={results} elementName={submittedTarget} />
                 </div>
               ) : viewMode === "process" ? (
-                <LiveSearchVisualizer searchSteps={response.search_steps} targetElement={submittedTarget} />
+                <LiveSearchVisualizer searchSteps={searchSteps} targetElement={submittedTarget} />
               ) : results.length > 0 ? (
                 <div className="recipe-trees">
                   <h2>